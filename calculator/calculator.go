@@ -0,0 +1,375 @@
+/* Logic to estimate the federal, state and local taxes on a gross income from the tax data loaded by the ETL */
+
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// the additional child tax credit is refundable up to a share of the earned income over a floor, which are set by
+// statute rather than adjusted each year
+const (
+	ACTC_EARNED_INCOME_FLOOR float64 = 2500
+	ACTC_EARNED_INCOME_RATE  float64 = 0.15
+	// the child tax credit phases out by its rate for each step of income over the threshold, or part of a step
+	CTC_PHASEOUT_STEP float64 = 1000
+)
+
+// the most qualifying children the earned income tax credit counts
+const EITC_MAX_CHILDREN int = 3
+
+// the jurisdiction of the federal estimate
+const FEDERAL_JURISDICTION string = "federal"
+
+// the household and place to estimate the taxes of. The income is taken to be wages, so it is both the gross and
+// earned income, and dependents are taken to be children qualifying for the child tax credit and the earned income
+// tax credit.
+type Input struct {
+	GrossIncome  float64
+	FilingStatus int
+	Dependents   int
+	// conditions of age 65 or over or blindness of the filer and spouse, each adding the additional standard deduction
+	Conditions int
+	// the county or tax locale to estimate the state and local taxes of. A tax locale takes precedence, and only the
+	// county level jurisdictions of a county are taxed, as the other jurisdictions of a county apply to part of it.
+	CountyId    int
+	TaxLocaleId int
+}
+
+// a line of an estimate, the amount of an item such as a bracket or credit. The amount is the rate applied to the
+// base if the item has a rate. Credits have negative amounts.
+type Line struct {
+	Item   string
+	Base   float64
+	Rate   float64
+	Amount float64
+}
+
+// the estimate of a jurisdiction. The tax is the total of the lines, and the deductions are the amounts taken from
+// the gross income to reach the taxable income.
+type JurisdictionEstimate struct {
+	Jurisdiction  string
+	Deductions    []Line
+	TaxableIncome float64
+	Lines         []Line
+	Tax           float64
+}
+
+// the estimated taxes of an input, by level of government. Notes describe the simplifications of the estimate.
+type Estimate struct {
+	TaxYear int
+	Federal JurisdictionEstimate
+	State   JurisdictionEstimate
+	// a jurisdiction estimate per local tax jurisdiction taxing the input
+	Local []JurisdictionEstimate
+	Total float64
+	Notes []string
+}
+
+// public method to estimate the federal, state and local taxes of the given input. An error is returned if the input
+// names a filing status, county or tax locale without data.
+func (data *TaxData) Estimate(input Input) (Estimate, error) {
+	if _, ok := data.FilingStatuses[input.FilingStatus]; !ok {
+		return Estimate{}, fmt.Errorf("There is no filing status with id %v", input.FilingStatus)
+	}
+	if input.GrossIncome < 0 || input.Dependents < 0 || input.Conditions < 0 {
+		return Estimate{}, fmt.Errorf("The income, dependents and conditions cannot be negative")
+	}
+
+	stateId, locales, err := data.getPlace(input)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate := Estimate{TaxYear: data.TaxYear}
+	estimate.Federal, err = data.estimateFederal(input, &estimate.Notes)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate.State, err = data.estimateState(input, stateId, &estimate.Notes)
+	if err != nil {
+		return Estimate{}, err
+	}
+
+	estimate.Notes = append(estimate.Notes, getEditionNote("state", data.StateEdition, data.TaxYear))
+	if len(locales) > 0 {
+		estimate.Notes = append(estimate.Notes, getEditionNote("local", data.LocalEdition, data.TaxYear))
+	}
+
+	estimate.Total = estimate.Federal.Tax + estimate.State.Tax
+	for _, id := range locales {
+		local := data.estimateLocal(input, data.Locales[id], estimate.State.Tax, &estimate.Notes)
+		estimate.Local = append(estimate.Local, local)
+		estimate.Total += local.Tax
+	}
+	estimate.Total = roundCents(estimate.Total)
+
+	return estimate, nil
+}
+
+// helper method returning the state and the local tax jurisdictions taxing an input
+func (data *TaxData) getPlace(input Input) (int, []int, error) {
+	if input.TaxLocaleId != 0 {
+		locale, ok := data.Locales[input.TaxLocaleId]
+		if !ok {
+			return 0, nil, fmt.Errorf("There is no tax locale with id %v", input.TaxLocaleId)
+		}
+		if locale.StateId == 0 {
+			return 0, nil, fmt.Errorf("The state of tax locale %s is not known", locale.Name)
+		}
+
+		return locale.StateId, []int{locale.Id}, nil
+	}
+
+	stateId, ok := data.CountyStates[input.CountyId]
+	if !ok {
+		return 0, nil, fmt.Errorf("There is no county with id %v", input.CountyId)
+	}
+
+	var locales []int
+	for _, id := range data.CountyLocales[input.CountyId] {
+		if data.Locales[id].JurisdictionType == JURISDICTION_COUNTY {
+			locales = append(locales, id)
+		}
+	}
+
+	return stateId, locales, nil
+}
+
+// helper method to estimate the federal income and payroll taxes of an input
+func (data *TaxData) estimateFederal(input Input, notes *[]string) (JurisdictionEstimate, error) {
+	status := input.FilingStatus
+	brackets, ok := data.Federal.Brackets[status]
+	if !ok {
+		return JurisdictionEstimate{}, fmt.Errorf("There are no federal brackets for filing status %s", data.FilingStatuses[status])
+	}
+
+	estimate := JurisdictionEstimate{Jurisdiction: FEDERAL_JURISDICTION}
+	estimate.Deductions = append(estimate.Deductions, Line{Item: "standard deduction", Amount: data.Federal.Deductions[status]})
+	if input.Conditions > 0 {
+		additional := data.Federal.AdditionalDeductions[status] * float64(input.Conditions)
+		estimate.Deductions = append(estimate.Deductions, Line{Item: "additional standard deduction for age or blindness", Amount: additional})
+	}
+	estimate.TaxableIncome = getTaxableIncome(input.GrossIncome, estimate.Deductions)
+
+	estimate.Lines = getBracketLines("income tax", brackets, estimate.TaxableIncome)
+	incomeTax := sumLines(estimate.Lines)
+
+	// the child tax credit is taken against the income tax, and the rest of it is refunded up to the refundable limits
+	if credit, ok := data.Federal.ChildTaxCredits[status]; ok && input.Dependents > 0 {
+		total := credit.PerChild * float64(input.Dependents)
+		if over := input.GrossIncome - credit.PhaseoutThreshold; over > 0 {
+			total -= math.Ceil(over/CTC_PHASEOUT_STEP) * CTC_PHASEOUT_STEP * credit.PhaseoutRate
+		}
+		total = math.Max(total, 0)
+
+		nonrefundable := math.Min(total, incomeTax)
+		refundable := math.Min(total-nonrefundable, credit.RefundablePerChild*float64(input.Dependents))
+		refundable = math.Min(refundable, math.Max(input.GrossIncome-ACTC_EARNED_INCOME_FLOOR, 0)*ACTC_EARNED_INCOME_RATE)
+		estimate.Lines = append(estimate.Lines,
+			Line{Item: "child tax credit", Amount: -nonrefundable},
+			Line{Item: "additional child tax credit", Amount: -refundable})
+	}
+
+	// the earned income tax credit rises to its maximum, holds, then phases out to zero
+	if byChildren, ok := data.Federal.Eitc[status]; ok {
+		children := input.Dependents
+		if children > EITC_MAX_CHILDREN {
+			children = EITC_MAX_CHILDREN
+		}
+		if e, ok := byChildren[children]; ok {
+			credit := getEitc(e, input.GrossIncome)
+			if credit > 0 {
+				estimate.Lines = append(estimate.Lines, Line{Item: "earned income tax credit", Base: input.GrossIncome, Amount: -credit})
+			}
+		}
+	} else {
+		*notes = append(*notes, fmt.Sprintf("No earned income tax credit is loaded for filing status %s", data.FilingStatuses[status]))
+	}
+
+	// the employee share of the payroll taxes on wages
+	for _, tax := range data.Federal.PayrollTaxes[status] {
+		base := input.GrossIncome
+		if tax.WageBase != nil {
+			base = math.Min(base, *tax.WageBase)
+		}
+		if tax.Threshold != nil {
+			base = math.Max(base-*tax.Threshold, 0)
+		}
+		if base > 0 {
+			estimate.Lines = append(estimate.Lines, Line{Item: tax.Name + " tax", Base: base, Rate: tax.EmployeeRate, Amount: base * tax.EmployeeRate})
+		}
+	}
+	if len(data.Federal.PayrollTaxes[status]) == 0 {
+		*notes = append(*notes, fmt.Sprintf("No payroll taxes are loaded for filing status %s", data.FilingStatuses[status]))
+	}
+
+	return finishEstimate(estimate), nil
+}
+
+// helper method to estimate the state income tax of an input
+func (data *TaxData) estimateState(input Input, stateId int, notes *[]string) (JurisdictionEstimate, error) {
+	state, ok := data.States[stateId]
+	if !ok {
+		return JurisdictionEstimate{}, fmt.Errorf("There is no state with id %v", stateId)
+	}
+
+	estimate := JurisdictionEstimate{Jurisdiction: state.Name}
+	if state.NoIncomeTax || state.InterestDividendsOnly || state.CapitalGainsOnly {
+		if state.InterestDividendsOnly || state.CapitalGainsOnly {
+			*notes = append(*notes, fmt.Sprintf("%s does not tax wages, so no state income tax is estimated", state.Name))
+		}
+		return estimate, nil
+	}
+
+	brackets, ok := state.Brackets[input.FilingStatus]
+	if !ok {
+		return JurisdictionEstimate{}, fmt.Errorf("There are no brackets of %s for filing status %s", state.Name, data.FilingStatuses[input.FilingStatus])
+	}
+
+	// deductions and exemptions given as credits are taken from the tax rather than the income
+	deduction := state.Deductions[input.FilingStatus]
+	if deduction.FallbackRule != "" {
+		*notes = append(*notes, fmt.Sprintf("%s does not publish a schedule for filing status %s, so it is taken as %s", state.Name,
+			data.FilingStatuses[input.FilingStatus], deduction.FallbackRule))
+	}
+
+	var credits []Line
+	addItem := func(item string, amount float64, credit bool) {
+		if amount <= 0 {
+			return
+		}
+		if credit {
+			credits = append(credits, Line{Item: item + " credit", Amount: -amount})
+		} else {
+			estimate.Deductions = append(estimate.Deductions, Line{Item: item, Amount: amount})
+		}
+	}
+	addItem("standard deduction", deduction.Deduction, deduction.DeductionCredit)
+	addItem("personal exemption", deduction.Exemption, deduction.ExemptionCredit)
+	addItem("dependent exemption", state.DependentExemption*float64(input.Dependents), state.DependentExemptionCredit)
+	estimate.TaxableIncome = getTaxableIncome(input.GrossIncome, estimate.Deductions)
+
+	estimate.Lines = getBracketLines("income tax", brackets, estimate.TaxableIncome)
+	// credits are not refundable, so they take the tax to zero at most
+	tax := sumLines(estimate.Lines)
+	for _, credit := range credits {
+		credit.Amount = -math.Min(-credit.Amount, tax)
+		tax += credit.Amount
+		estimate.Lines = append(estimate.Lines, credit)
+	}
+
+	return finishEstimate(estimate), nil
+}
+
+// helper method to estimate the resident tax of a local tax jurisdiction on an input, given the state tax
+func (data *TaxData) estimateLocal(input Input, locale Locale, stateTax float64, notes *[]string) JurisdictionEstimate {
+	estimate := JurisdictionEstimate{Jurisdiction: locale.Name, TaxableIncome: input.GrossIncome}
+	if locale.RateNote != "" {
+		*notes = append(*notes, fmt.Sprintf("The rate of %s has the condition: %s", locale.Name, locale.RateNote))
+	}
+
+	if locale.Rate > 0 {
+		estimate.Lines = append(estimate.Lines, Line{Item: "income tax", Base: input.GrossIncome, Rate: locale.Rate, Amount: input.GrossIncome * locale.Rate})
+	}
+	if locale.AnnualFlatFee > 0 {
+		estimate.Lines = append(estimate.Lines, Line{Item: "annual flat fee", Amount: locale.AnnualFlatFee})
+	}
+	if locale.StateRate > 0 {
+		estimate.Lines = append(estimate.Lines, Line{Item: "share of state tax", Base: stateTax, Rate: locale.StateRate, Amount: stateTax * locale.StateRate})
+	}
+
+	return finishEstimate(estimate)
+}
+
+// helper method returning the earned income tax credit on an income
+func getEitc(e Eitc, income float64) float64 {
+	if e.IncomeAtMaxCredit <= 0 || income >= e.PhaseoutEnds {
+		return 0
+	}
+
+	credit := e.MaxCredit * math.Min(income/e.IncomeAtMaxCredit, 1)
+	if income > e.PhaseoutBegins && e.PhaseoutEnds > e.PhaseoutBegins {
+		credit = math.Min(credit, e.MaxCredit*(e.PhaseoutEnds-income)/(e.PhaseoutEnds-e.PhaseoutBegins))
+	}
+
+	return math.Max(credit, 0)
+}
+
+// helper method returning the income left after the deductions, zero at least
+func getTaxableIncome(income float64, deductions []Line) float64 {
+	return math.Max(income-sumLines(deductions), 0)
+}
+
+// helper method returning a line for each bracket of a schedule the taxable income reaches
+func getBracketLines(item string, brackets []Bracket, taxableIncome float64) []Line {
+	var lines []Line
+	for _, b := range brackets {
+		if taxableIncome <= b.LowerBound {
+			break
+		}
+
+		top := taxableIncome
+		if b.UpperBound != nil {
+			top = math.Min(top, *b.UpperBound)
+		}
+		base := top - b.LowerBound
+		lines = append(lines, Line{Item: fmt.Sprintf("%s at %s", item, formatRate(b.Rate)), Base: base, Rate: b.Rate, Amount: base * b.Rate})
+	}
+
+	return lines
+}
+
+// helper method to round the amounts of an estimate to cents and total its lines
+func finishEstimate(estimate JurisdictionEstimate) JurisdictionEstimate {
+	for _, lines := range [][]Line{estimate.Deductions, estimate.Lines} {
+		for i := range lines {
+			lines[i].Base = roundCents(lines[i].Base)
+			lines[i].Amount = roundCents(lines[i].Amount)
+		}
+	}
+	estimate.TaxableIncome = roundCents(estimate.TaxableIncome)
+	estimate.Tax = roundCents(sumLines(estimate.Lines))
+
+	return estimate
+}
+
+// helper method returning the total of the amounts of lines
+func sumLines(lines []Line) float64 {
+	total := 0.0
+	for _, line := range lines {
+		total += line.Amount
+	}
+
+	return total
+}
+
+// helper method returning the note on the edition of the state or local tax data, which may give another tax year
+// than the federal data
+func getEditionNote(level string, edition *Edition, taxYear int) string {
+	if edition == nil {
+		return fmt.Sprintf("The edition of the %s tax data is unknown, so it may not be of tax year %v", level, taxYear)
+	} else if edition.TaxYear != taxYear {
+		return fmt.Sprintf("The %s tax data is from the %v edition for tax year %v rather than tax year %v", level,
+			edition.PublishedYear, edition.TaxYear, taxYear)
+	}
+
+	return fmt.Sprintf("The %s tax data is from the %v edition for tax year %v", level, edition.PublishedYear, edition.TaxYear)
+}
+
+// helper method to round an amount to cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// helper method to format a rate as a percentage, i.e "5.75%"
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%s%%", formatNumber(rate*100))
+}
+
+// helper method to format a number with as many digits as it needs, up to 4 places
+func formatNumber(n float64) string {
+	return fmt.Sprint(math.Round(n*10000) / 10000)
+}
@@ -0,0 +1,414 @@
+package calculator
+
+import (
+	"strings"
+	"testing"
+)
+
+// ids of the test counties and locales
+const (
+	franklinCountyId = 39049
+	delawareCountyId = 39041
+	franklinLocaleId = 1
+	columbusLocaleId = 2
+	unknownLocaleId  = 3
+)
+
+// helper method returning a pointer to an amount, as for an upper bound
+func amount(a float64) *float64 {
+	return &a
+}
+
+// helper method building a schedule from the lower bound of each bracket and its rate, the last without an upper bound
+func newSchedule(lowers []float64, rates []float64) []Bracket {
+	var brackets []Bracket
+	for i := range lowers {
+		b := Bracket{Rate: rates[i], LowerBound: lowers[i]}
+		if i+1 < len(lowers) {
+			b.UpperBound = amount(lowers[i+1])
+		}
+		brackets = append(brackets, b)
+	}
+
+	return brackets
+}
+
+// helper method building the tax data of 2022 for single and married filing jointly filers, with Ohio as the only
+// state and Columbus in Franklin County as its only city
+func newTestTaxData() *TaxData {
+	rates := []float64{0.1, 0.12, 0.22, 0.24, 0.32, 0.35, 0.37}
+	ohio := State{
+		Id:                 39,
+		Name:               "Ohio",
+		DependentExemption: 2400,
+		Brackets: map[int][]Bracket{
+			SINGLE_STATUS:        newSchedule([]float64{0, 25000, 44250, 88450, 110650}, []float64{0, 0.02765, 0.03226, 0.03688, 0.0399}),
+			MARRIED_JOINT_STATUS: newSchedule([]float64{0, 25000, 44250, 88450, 110650}, []float64{0, 0.02765, 0.03226, 0.03688, 0.0399}),
+		},
+		Deductions: map[int]StateDeduction{
+			SINGLE_STATUS:        {Exemption: 2400},
+			MARRIED_JOINT_STATUS: {Exemption: 4800},
+		},
+	}
+
+	payrollTaxes := func(threshold float64) []PayrollTax {
+		return []PayrollTax{
+			{Name: "social security", EmployeeRate: 0.062, EmployerRate: 0.062, WageBase: amount(147000)},
+			{Name: "medicare", EmployeeRate: 0.0145, EmployerRate: 0.0145},
+			{Name: "additional medicare", EmployeeRate: 0.009, Threshold: amount(threshold)},
+		}
+	}
+
+	return &TaxData{
+		TaxYear:        2022,
+		StateEdition:   &Edition{PublishedYear: 2022, TaxYear: 2022},
+		LocalEdition:   &Edition{PublishedYear: 2023, TaxYear: 2023},
+		FilingStatuses: map[int]string{SINGLE_STATUS: "single", MARRIED_JOINT_STATUS: "married filing jointly"},
+		Federal: FederalData{
+			Brackets: map[int][]Bracket{
+				SINGLE_STATUS:        newSchedule([]float64{0, 10275, 41775, 89075, 170050, 215950, 539900}, rates),
+				MARRIED_JOINT_STATUS: newSchedule([]float64{0, 20550, 83550, 178150, 340100, 431900, 647850}, rates),
+			},
+			Deductions:           map[int]float64{SINGLE_STATUS: 12950, MARRIED_JOINT_STATUS: 25900},
+			AdditionalDeductions: map[int]float64{SINGLE_STATUS: 1750, MARRIED_JOINT_STATUS: 1400},
+			ChildTaxCredits: map[int]ChildTaxCredit{
+				SINGLE_STATUS:        {PerChild: 2000, RefundablePerChild: 1500, OtherDependent: 500, PhaseoutThreshold: 200000, PhaseoutRate: 0.05},
+				MARRIED_JOINT_STATUS: {PerChild: 2000, RefundablePerChild: 1500, OtherDependent: 500, PhaseoutThreshold: 400000, PhaseoutRate: 0.05},
+			},
+			Eitc: map[int]map[int]Eitc{
+				SINGLE_STATUS: {
+					0: {IncomeAtMaxCredit: 7320, MaxCredit: 560, PhaseoutBegins: 9160, PhaseoutEnds: 16480},
+				},
+				MARRIED_JOINT_STATUS: {
+					0: {IncomeAtMaxCredit: 7320, MaxCredit: 560, PhaseoutBegins: 15290, PhaseoutEnds: 22610},
+					2: {IncomeAtMaxCredit: 15410, MaxCredit: 6164, PhaseoutBegins: 26260, PhaseoutEnds: 59187},
+				},
+			},
+			PayrollTaxes: map[int][]PayrollTax{
+				SINGLE_STATUS:        payrollTaxes(200000),
+				MARRIED_JOINT_STATUS: payrollTaxes(250000),
+			},
+		},
+		States:       map[int]State{39: ohio},
+		CountyStates: map[int]int{franklinCountyId: 39, delawareCountyId: 39},
+		Locales: map[int]Locale{
+			franklinLocaleId: {Id: franklinLocaleId, Name: "Franklin County", StateId: 39, JurisdictionType: JURISDICTION_COUNTY, AnnualFlatFee: 10},
+			columbusLocaleId: {Id: columbusLocaleId, Name: "Columbus", StateId: 39, JurisdictionType: "city", Rate: 0.025},
+			unknownLocaleId:  {Id: unknownLocaleId, Name: "Nowhere", JurisdictionType: "city", Rate: 0.01},
+		},
+		CountyLocales: map[int][]int{
+			franklinCountyId: {columbusLocaleId, franklinLocaleId},
+			delawareCountyId: {columbusLocaleId},
+		},
+	}
+}
+
+// helper method returning the amount of the line of an item, and whether there is one
+func findLine(lines []Line, item string) (float64, bool) {
+	for _, line := range lines {
+		if line.Item == item {
+			return line.Amount, true
+		}
+	}
+
+	return 0, false
+}
+
+func TestGetBracketLines(t *testing.T) {
+	brackets := newTestTaxData().Federal.Brackets[SINGLE_STATUS]
+	tests := []struct {
+		taxableIncome float64
+		lines         int
+		tax           float64
+	}{
+		{0, 0, 0},
+		{1, 1, 0.1},
+		// the lower bound of a bracket is taxed by the bracket below it
+		{10275, 1, 1027.5},
+		{10276, 2, 1027.62},
+		{41775, 2, 4807.5},
+		{41776, 3, 4807.72},
+		{539900, 6, 162718},
+		{600000, 7, 184955},
+	}
+
+	for _, tt := range tests {
+		lines := getBracketLines("income tax", brackets, tt.taxableIncome)
+		if len(lines) != tt.lines {
+			t.Errorf("getBracketLines(%v) returned %v lines, want %v", tt.taxableIncome, len(lines), tt.lines)
+		}
+		if tax := roundCents(sumLines(lines)); tax != tt.tax {
+			t.Errorf("getBracketLines(%v) totals %v, want %v", tt.taxableIncome, tax, tt.tax)
+		}
+	}
+}
+
+func TestChildTaxCredit(t *testing.T) {
+	data := newTestTaxData()
+	tests := []struct {
+		income        float64
+		nonrefundable float64
+		refundable    float64
+	}{
+		// the income tax of 410 on 4,100 of taxable income takes part of the credit, and the rest is refunded up to
+		// 1,500 per child
+		{30000, 410, 3000},
+		// with no income tax the refund is held to 15% of earned income over 2,500
+		{20000, 0, 2625},
+		{2000, 0, 0},
+		// the credit falls by 50 for each 1,000 over the threshold, or part of 1,000
+		{400000, 4000, 0},
+		{400001, 3950, 0},
+		{401000, 3950, 0},
+		{401001, 3900, 0},
+		{479001, 0, 0},
+		{500000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		var notes []string
+		estimate, err := data.estimateFederal(Input{GrossIncome: tt.income, FilingStatus: MARRIED_JOINT_STATUS, Dependents: 2}, &notes)
+		if err != nil {
+			t.Fatalf("estimateFederal(%v) returned error %s", tt.income, err)
+		}
+
+		nonrefundable, _ := findLine(estimate.Lines, "child tax credit")
+		refundable, _ := findLine(estimate.Lines, "additional child tax credit")
+		if -nonrefundable != tt.nonrefundable || -refundable != tt.refundable {
+			t.Errorf("child tax credit of %v = %v and %v refundable, want %v and %v", tt.income, -nonrefundable, -refundable,
+				tt.nonrefundable, tt.refundable)
+		}
+	}
+}
+
+func TestGetEitc(t *testing.T) {
+	e := newTestTaxData().Federal.Eitc[MARRIED_JOINT_STATUS][2]
+	tests := []struct {
+		income float64
+		credit float64
+	}{
+		{0, 0},
+		// the credit rises to its maximum
+		{7705, 3082},
+		{15410, 6164},
+		// holds across the plateau
+		{20000, 6164},
+		{26260, 6164},
+		// and phases out to zero
+		{42723.5, 3082},
+		{50000, 1719.82},
+		{59187, 0},
+		{70000, 0},
+	}
+
+	for _, tt := range tests {
+		if credit := roundCents(getEitc(e, tt.income)); credit != tt.credit {
+			t.Errorf("getEitc(%v) = %v, want %v", tt.income, credit, tt.credit)
+		}
+	}
+}
+
+func TestPayrollTaxes(t *testing.T) {
+	data := newTestTaxData()
+	tests := []struct {
+		income             float64
+		socialSecurity     float64
+		medicare           float64
+		additionalMedicare float64
+	}{
+		{100000, 6200, 1450, 0},
+		// social security is only taxed up to the wage base
+		{147000, 9114, 2131.5, 0},
+		{200000, 9114, 2900, 0},
+		// additional medicare is only taxed over the threshold
+		{250000, 9114, 3625, 450},
+	}
+
+	for _, tt := range tests {
+		var notes []string
+		estimate, err := data.estimateFederal(Input{GrossIncome: tt.income, FilingStatus: SINGLE_STATUS}, &notes)
+		if err != nil {
+			t.Fatalf("estimateFederal(%v) returned error %s", tt.income, err)
+		}
+
+		socialSecurity, _ := findLine(estimate.Lines, "social security tax")
+		medicare, _ := findLine(estimate.Lines, "medicare tax")
+		additionalMedicare, ok := findLine(estimate.Lines, "additional medicare tax")
+		if ok != (tt.additionalMedicare > 0) {
+			t.Errorf("payroll taxes of %v have an additional medicare line: %v", tt.income, ok)
+		}
+		if socialSecurity != tt.socialSecurity || medicare != tt.medicare || additionalMedicare != tt.additionalMedicare {
+			t.Errorf("payroll taxes of %v = %v, %v and %v, want %v, %v and %v", tt.income, socialSecurity, medicare,
+				additionalMedicare, tt.socialSecurity, tt.medicare, tt.additionalMedicare)
+		}
+	}
+}
+
+func TestStateCredits(t *testing.T) {
+	data := newTestTaxData()
+	// a flat 5% tax with its standard deduction and dependent exemption given as credits
+	data.States[39] = State{
+		Id:                       39,
+		Name:                     "Ohio",
+		DependentExemption:       500,
+		DependentExemptionCredit: true,
+		Brackets:                 map[int][]Bracket{SINGLE_STATUS: {{Rate: 0.05}}},
+		Deductions:               map[int]StateDeduction{SINGLE_STATUS: {Deduction: 1000, Exemption: 2000, DeductionCredit: true}},
+	}
+
+	tests := []struct {
+		income          float64
+		taxableIncome   float64
+		deductionCredit float64
+		dependentCredit float64
+		tax             float64
+	}{
+		// the exemption is taken from the income and the credits from the tax
+		{50000, 48000, 1000, 1000, 400},
+		// credits are not refundable, so they take the tax to zero at most
+		{10000, 8000, 400, 0, 0},
+		{30000, 28000, 1000, 400, 0},
+	}
+
+	for _, tt := range tests {
+		var notes []string
+		estimate, err := data.estimateState(Input{GrossIncome: tt.income, FilingStatus: SINGLE_STATUS, Dependents: 2}, 39, &notes)
+		if err != nil {
+			t.Fatalf("estimateState(%v) returned error %s", tt.income, err)
+		}
+
+		deductionCredit, _ := findLine(estimate.Lines, "standard deduction credit")
+		dependentCredit, _ := findLine(estimate.Lines, "dependent exemption credit")
+		if estimate.TaxableIncome != tt.taxableIncome || -deductionCredit != tt.deductionCredit ||
+			-dependentCredit != tt.dependentCredit || estimate.Tax != tt.tax {
+			t.Errorf("state tax of %v = %v taxable, credits of %v and %v, %v tax, want %v taxable, credits of %v and %v, %v tax",
+				tt.income, estimate.TaxableIncome, -deductionCredit, -dependentCredit, estimate.Tax, tt.taxableIncome,
+				tt.deductionCredit, tt.dependentCredit, tt.tax)
+		}
+	}
+}
+
+func TestGetPlace(t *testing.T) {
+	data := newTestTaxData()
+	tests := []struct {
+		name    string
+		input   Input
+		stateId int
+		locales []int
+		err     bool
+	}{
+		// a county is taxed by its county level jurisdictions only
+		{"county", Input{CountyId: franklinCountyId}, 39, []int{franklinLocaleId}, false},
+		{"county without a county tax", Input{CountyId: delawareCountyId}, 39, nil, false},
+		// a tax locale is taxed by itself, taking precedence over a county
+		{"tax locale", Input{TaxLocaleId: columbusLocaleId}, 39, []int{columbusLocaleId}, false},
+		{"tax locale and county", Input{TaxLocaleId: columbusLocaleId, CountyId: delawareCountyId}, 39, []int{columbusLocaleId}, false},
+		{"unknown county", Input{CountyId: 1001}, 0, nil, true},
+		{"unknown tax locale", Input{TaxLocaleId: 99}, 0, nil, true},
+		{"tax locale without a state", Input{TaxLocaleId: unknownLocaleId}, 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		stateId, locales, err := data.getPlace(tt.input)
+		if (err != nil) != tt.err {
+			t.Errorf("getPlace of %s returned error %v", tt.name, err)
+			continue
+		}
+		if stateId != tt.stateId || len(locales) != len(tt.locales) {
+			t.Errorf("getPlace of %s = %v, %v, want %v, %v", tt.name, stateId, locales, tt.stateId, tt.locales)
+			continue
+		}
+		for i := range locales {
+			if locales[i] != tt.locales[i] {
+				t.Errorf("getPlace of %s = %v, %v, want %v, %v", tt.name, stateId, locales, tt.stateId, tt.locales)
+			}
+		}
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	data := newTestTaxData()
+	tests := []struct {
+		name    string
+		input   Input
+		federal float64
+		state   float64
+		local   []float64
+		total   float64
+		notes   []string
+	}{
+		{
+			// taxable income of 24,100 is taxed 2,481, all taken by the child tax credit, which refunds 1,519 more.
+			// The earned income tax credit is 1,719.82 and the payroll taxes 3,825. Ohio taxes 40,400 of income
+			// after 9,600 of exemptions at 2.765% over 25,000, and Columbus taxes the gross income at 2.5%.
+			name:    "married filing jointly in Columbus",
+			input:   Input{GrossIncome: 50000, FilingStatus: MARRIED_JOINT_STATUS, Dependents: 2, TaxLocaleId: columbusLocaleId},
+			federal: 586.18,
+			state:   425.81,
+			local:   []float64{1250},
+			total:   2261.99,
+			notes: []string{"The state tax data is from the 2022 edition for tax year 2022",
+				"The local tax data is from the 2023 edition for tax year 2023 rather than tax year 2022"},
+		},
+		{
+			// taxable income of 25,300 after the standard deduction and one additional deduction is taxed 2,830.5,
+			// and the payroll taxes are 3,060. Ohio taxes 37,600 at 2.765% over 25,000, and Franklin County charges
+			// its flat fee.
+			name:    "single over 65 in Franklin County",
+			input:   Input{GrossIncome: 40000, FilingStatus: SINGLE_STATUS, Conditions: 1, CountyId: franklinCountyId},
+			federal: 5890.5,
+			state:   348.39,
+			local:   []float64{10},
+			total:   6248.89,
+			notes: []string{"The state tax data is from the 2022 edition for tax year 2022",
+				"The local tax data is from the 2023 edition for tax year 2023 rather than tax year 2022"},
+		},
+		{
+			name:    "single without local taxes",
+			input:   Input{GrossIncome: 40000, FilingStatus: SINGLE_STATUS, CountyId: delawareCountyId},
+			federal: 6100.5,
+			state:   348.39,
+			total:   6448.89,
+			notes:   []string{"The state tax data is from the 2022 edition for tax year 2022"},
+		},
+	}
+
+	for _, tt := range tests {
+		estimate, err := data.Estimate(tt.input)
+		if err != nil {
+			t.Errorf("Estimate of %s returned error %s", tt.name, err)
+			continue
+		}
+
+		if estimate.Federal.Tax != tt.federal || estimate.State.Tax != tt.state || estimate.Total != tt.total {
+			t.Errorf("Estimate of %s = %v federal, %v state, %v total, want %v, %v, %v", tt.name, estimate.Federal.Tax,
+				estimate.State.Tax, estimate.Total, tt.federal, tt.state, tt.total)
+		}
+		if len(estimate.Local) != len(tt.local) {
+			t.Errorf("Estimate of %s has %v local estimates, want %v", tt.name, len(estimate.Local), len(tt.local))
+		} else {
+			for i, local := range estimate.Local {
+				if local.Tax != tt.local[i] {
+					t.Errorf("Estimate of %s has local tax %v of %s, want %v", tt.name, local.Tax, local.Jurisdiction, tt.local[i])
+				}
+			}
+		}
+		if strings.Join(estimate.Notes, "\n") != strings.Join(tt.notes, "\n") {
+			t.Errorf("Estimate of %s has notes %q, want %q", tt.name, estimate.Notes, tt.notes)
+		}
+	}
+}
+
+func TestEstimateErrors(t *testing.T) {
+	data := newTestTaxData()
+	for _, input := range []Input{
+		{GrossIncome: 50000, FilingStatus: HEAD_OF_HOUSEHOLD_STATUS, CountyId: franklinCountyId},
+		{GrossIncome: -1, FilingStatus: SINGLE_STATUS, CountyId: franklinCountyId},
+		{GrossIncome: 50000, FilingStatus: SINGLE_STATUS, Dependents: -1, CountyId: franklinCountyId},
+		{GrossIncome: 50000, FilingStatus: SINGLE_STATUS, CountyId: 1001},
+	} {
+		if _, err := data.Estimate(input); err == nil {
+			t.Errorf("Estimate of %+v returned no error", input)
+		}
+	}
+}
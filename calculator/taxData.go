@@ -0,0 +1,421 @@
+/* Logic to read the federal, state and local tax tables into memory for the calculator */
+
+package calculator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ids of the filing statuses, as loaded to the filing status table
+const (
+	SINGLE_STATUS            int = 1
+	MARRIED_JOINT_STATUS     int = 2
+	HEAD_OF_HOUSEHOLD_STATUS int = 3
+	MARRIED_SEPARATE_STATUS  int = 4
+)
+
+// id of the null state record, which holds no tax data
+const stateNullId int = 32767
+
+// type of a local tax jurisdiction levied by a county, applying to every resident of the county
+const JURISDICTION_COUNTY string = "county"
+
+// a bracket of a schedule. Rates are fractions, and the top bracket has no upper bound.
+type Bracket struct {
+	Rate       float64
+	LowerBound float64
+	UpperBound *float64
+}
+
+// the child tax credit of a filing status
+type ChildTaxCredit struct {
+	PerChild           float64
+	RefundablePerChild float64
+	OtherDependent     float64
+	PhaseoutThreshold  float64
+	PhaseoutRate       float64
+}
+
+// the earned income tax credit of a filing status and number of qualifying children
+type Eitc struct {
+	IncomeAtMaxCredit float64
+	MaxCredit         float64
+	PhaseoutBegins    float64
+	PhaseoutEnds      float64
+}
+
+// a payroll tax of a filing status. A tax on all wages has no wage base, and a tax from the first dollar no threshold.
+type PayrollTax struct {
+	Name         string
+	EmployeeRate float64
+	EmployerRate float64
+	WageBase     *float64
+	Threshold    *float64
+}
+
+// the federal tax data of a tax year, by filing status id
+type FederalData struct {
+	Brackets             map[int][]Bracket
+	Deductions           map[int]float64
+	AdditionalDeductions map[int]float64
+	ChildTaxCredits      map[int]ChildTaxCredit
+	// by filing status id and then qualifying children, up to the most the credit counts
+	Eitc         map[int]map[int]Eitc
+	PayrollTaxes map[int][]PayrollTax
+}
+
+// the standard deduction and personal exemption of a state and filing status
+type StateDeduction struct {
+	Deduction       float64
+	Exemption       float64
+	DeductionCredit bool
+	ExemptionCredit bool
+	// the rule the deduction is derived by if the state does not publish it, empty if published
+	FallbackRule string
+}
+
+// the tax data of a state, with its brackets and deductions by filing status id
+type State struct {
+	Id                       int
+	Name                     string
+	Abbreviation             string
+	DependentExemption       float64
+	DependentExemptionCredit bool
+	NoIncomeTax              bool
+	InterestDividendsOnly    bool
+	CapitalGainsOnly         bool
+	Brackets                 map[int][]Bracket
+	Deductions               map[int]StateDeduction
+}
+
+// the resident tax of a local tax jurisdiction. Rates and the share of state liability are fractions.
+type Locale struct {
+	Id               int
+	Name             string
+	StateId          int
+	JurisdictionType string
+	Rate             float64
+	AnnualFlatFee    float64
+	StateRate        float64
+	// condition on the rate, such as only applying to dividends, empty if unconditional
+	RateNote string
+}
+
+// the edition of a source the tables were loaded from, the year it was published and the tax year it gives
+type Edition struct {
+	PublishedYear int
+	TaxYear       int
+}
+
+// the tax data the calculator estimates with, as loaded by the ETL
+type TaxData struct {
+	TaxYear int
+	// editions of the state and local tables, which are not keyed by tax year. Nil if the rows name no source.
+	StateEdition   *Edition
+	LocalEdition   *Edition
+	FilingStatuses map[int]string
+	Federal        FederalData
+	States         map[int]State
+	// the state of each county
+	CountyStates map[int]int
+	Locales      map[int]Locale
+	// the locales applying to each county
+	CountyLocales map[int][]int
+}
+
+// public method to read the tax tables into memory. The federal tables keyed by tax year are read for the given
+// year, or the latest year loaded if it is 0.
+func ReadTaxData(db *sql.DB, taxYear int) (*TaxData, error) {
+	if taxYear == 0 {
+		err := db.QueryRow("SELECT COALESCE(MAX(tax_year), 0) FROM federal_deductions;").Scan(&taxYear)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to find the latest tax year loaded: %s", err)
+		}
+		if taxYear == 0 {
+			return nil, fmt.Errorf("No federal deductions are loaded")
+		}
+	}
+
+	data := &TaxData{
+		TaxYear:        taxYear,
+		FilingStatuses: make(map[int]string),
+		Federal: FederalData{
+			Brackets:             make(map[int][]Bracket),
+			Deductions:           make(map[int]float64),
+			AdditionalDeductions: make(map[int]float64),
+			ChildTaxCredits:      make(map[int]ChildTaxCredit),
+			Eitc:                 make(map[int]map[int]Eitc),
+			PayrollTaxes:         make(map[int][]PayrollTax),
+		},
+		States:        make(map[int]State),
+		CountyStates:  make(map[int]int),
+		Locales:       make(map[int]Locale),
+		CountyLocales: make(map[int][]int),
+	}
+
+	// each table is read in turn, stopping at the first error
+	readers := []struct {
+		table string
+		read  func(*sql.DB, *TaxData) error
+	}{
+		{"filing_status", readFilingStatuses},
+		{"federal_brackets", readFederalBrackets},
+		{"federal_deductions", readFederalDeductions},
+		{"federal_additional_deductions", readFederalAdditionalDeductions},
+		{"federal_child_tax_credit", readFederalChildTaxCredits},
+		{"federal_eitc", readFederalEitc},
+		{"federal_payroll_taxes", readFederalPayrollTaxes},
+		{"states", readStates},
+		{"state_brackets", readStateBrackets},
+		{"state_deductions", readStateDeductions},
+		{"county", readCounties},
+		{"tax_locale", readLocales},
+		{"tax_locale_county", readLocaleCounties},
+		{"source_manifest", readEditions},
+	}
+	for _, r := range readers {
+		err := r.read(db, data)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read the %s table: %s", r.table, err)
+		}
+	}
+
+	return data, nil
+}
+
+// helper method to run a query and scan each of its rows with the given function
+func scanRows(db *sql.DB, query string, scan func(*sql.Rows) error, args ...interface{}) error {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		err = scan(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// helper method returning a nullable amount as a pointer, nil if null
+func nullableAmount(n sql.NullFloat64) *float64 {
+	if !n.Valid {
+		return nil
+	}
+
+	return &n.Float64
+}
+
+// helper method to read the filing statuses
+func readFilingStatuses(db *sql.DB, data *TaxData) error {
+	return scanRows(db, "SELECT filing_status_id, filing_status FROM filing_status;", func(rows *sql.Rows) error {
+		var id int
+		var name string
+		err := rows.Scan(&id, &name)
+		data.FilingStatuses[id] = name
+		return err
+	})
+}
+
+// helper method to read the federal brackets of the tax year, in order of ordinal
+func readFederalBrackets(db *sql.DB, data *TaxData) error {
+	query := `SELECT filing_status_id, rate, lower_bound, upper_bound FROM federal_brackets WHERE tax_year = $1
+		ORDER BY filing_status_id, ordinal;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status int
+		var b Bracket
+		var upper sql.NullFloat64
+		err := rows.Scan(&status, &b.Rate, &b.LowerBound, &upper)
+		b.UpperBound = nullableAmount(upper)
+		data.Federal.Brackets[status] = append(data.Federal.Brackets[status], b)
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the federal standard deductions of the tax year
+func readFederalDeductions(db *sql.DB, data *TaxData) error {
+	query := "SELECT filing_status_id, deduction FROM federal_deductions WHERE tax_year = $1;"
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status int
+		var deduction float64
+		err := rows.Scan(&status, &deduction)
+		data.Federal.Deductions[status] = deduction
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the federal additional standard deductions of the tax year
+func readFederalAdditionalDeductions(db *sql.DB, data *TaxData) error {
+	query := "SELECT filing_status_id, deduction FROM federal_additional_deductions WHERE tax_year = $1;"
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status int
+		var deduction float64
+		err := rows.Scan(&status, &deduction)
+		data.Federal.AdditionalDeductions[status] = deduction
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the federal child tax credit of the tax year
+func readFederalChildTaxCredits(db *sql.DB, data *TaxData) error {
+	query := `SELECT filing_status_id, credit_per_child, refundable_per_child, other_dependent_credit, phaseout_threshold, phaseout_rate
+		FROM federal_child_tax_credit WHERE tax_year = $1;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status int
+		var c ChildTaxCredit
+		err := rows.Scan(&status, &c.PerChild, &c.RefundablePerChild, &c.OtherDependent, &c.PhaseoutThreshold, &c.PhaseoutRate)
+		data.Federal.ChildTaxCredits[status] = c
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the federal earned income tax credit of the tax year
+func readFederalEitc(db *sql.DB, data *TaxData) error {
+	query := `SELECT filing_status_id, children, income_at_max_credit, max_credit, phaseout_begins, phaseout_ends
+		FROM federal_eitc WHERE tax_year = $1;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status, children int
+		var e Eitc
+		err := rows.Scan(&status, &children, &e.IncomeAtMaxCredit, &e.MaxCredit, &e.PhaseoutBegins, &e.PhaseoutEnds)
+		if data.Federal.Eitc[status] == nil {
+			data.Federal.Eitc[status] = make(map[int]Eitc)
+		}
+		data.Federal.Eitc[status][children] = e
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the federal payroll taxes of the tax year
+func readFederalPayrollTaxes(db *sql.DB, data *TaxData) error {
+	query := `SELECT filing_status_id, payroll_tax, employee_rate, employer_rate, wage_base, threshold
+		FROM federal_payroll_taxes WHERE tax_year = $1 ORDER BY filing_status_id, payroll_tax;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var status int
+		var t PayrollTax
+		var wageBase, threshold sql.NullFloat64
+		err := rows.Scan(&status, &t.Name, &t.EmployeeRate, &t.EmployerRate, &wageBase, &threshold)
+		t.WageBase = nullableAmount(wageBase)
+		t.Threshold = nullableAmount(threshold)
+		data.Federal.PayrollTaxes[status] = append(data.Federal.PayrollTaxes[status], t)
+		return err
+	}, data.TaxYear)
+}
+
+// helper method to read the states, other than the null state record
+func readStates(db *sql.DB, data *TaxData) error {
+	query := `SELECT state_id, state_name, COALESCE(abbreviation, ''), dependent_exemption, dependent_exemption_credit,
+		no_income_tax, interest_dividends_only, capital_gains_only FROM states WHERE state_id <> $1;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		s := State{Brackets: make(map[int][]Bracket), Deductions: make(map[int]StateDeduction)}
+		err := rows.Scan(&s.Id, &s.Name, &s.Abbreviation, &s.DependentExemption, &s.DependentExemptionCredit,
+			&s.NoIncomeTax, &s.InterestDividendsOnly, &s.CapitalGainsOnly)
+		data.States[s.Id] = s
+		return err
+	}, stateNullId)
+}
+
+// helper method to read the state brackets of the states read, in order of ordinal
+func readStateBrackets(db *sql.DB, data *TaxData) error {
+	query := "SELECT state_id, filing_status_id, rate, lower_bound, upper_bound FROM state_brackets ORDER BY state_id, filing_status_id, ordinal;"
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var stateId, status int
+		var b Bracket
+		var upper sql.NullFloat64
+		err := rows.Scan(&stateId, &status, &b.Rate, &b.LowerBound, &upper)
+		b.UpperBound = nullableAmount(upper)
+		if s, ok := data.States[stateId]; ok {
+			s.Brackets[status] = append(s.Brackets[status], b)
+		}
+		return err
+	})
+}
+
+// helper method to read the state deductions of the states read
+func readStateDeductions(db *sql.DB, data *TaxData) error {
+	query := `SELECT state_id, filing_status_id, deduction, exemption, deduction_credit, exemption_credit,
+		COALESCE(fallback_rule, '') FROM state_deductions;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var stateId, status int
+		var d StateDeduction
+		err := rows.Scan(&stateId, &status, &d.Deduction, &d.Exemption, &d.DeductionCredit, &d.ExemptionCredit, &d.FallbackRule)
+		if s, ok := data.States[stateId]; ok {
+			s.Deductions[status] = d
+		}
+		return err
+	})
+}
+
+// helper method to read the state of each county
+func readCounties(db *sql.DB, data *TaxData) error {
+	return scanRows(db, "SELECT county_id, state_id FROM county;", func(rows *sql.Rows) error {
+		var countyId, stateId int
+		err := rows.Scan(&countyId, &stateId)
+		data.CountyStates[countyId] = stateId
+		return err
+	})
+}
+
+// helper method to read the resident tax of each local tax jurisdiction, linked to the county it is matched to
+func readLocales(db *sql.DB, data *TaxData) error {
+	query := `SELECT tax_locale_id, tax_locale, COALESCE(state_id, 0), COALESCE(county_id, 0), jurisdiction_type,
+		resident_rate, resident_annual_flat_fee, resident_state_rate, COALESCE(resident_rate_note, '') FROM tax_locale;`
+	return scanRows(db, query, func(rows *sql.Rows) error {
+		var l Locale
+		var countyId int
+		err := rows.Scan(&l.Id, &l.Name, &l.StateId, &countyId, &l.JurisdictionType, &l.Rate, &l.AnnualFlatFee,
+			&l.StateRate, &l.RateNote)
+		data.Locales[l.Id] = l
+		if countyId != 0 {
+			data.CountyLocales[countyId] = append(data.CountyLocales[countyId], l.Id)
+		}
+		return err
+	})
+}
+
+// helper method to link the local tax jurisdictions spanning several counties to each of their other counties
+func readLocaleCounties(db *sql.DB, data *TaxData) error {
+	return scanRows(db, "SELECT tax_locale_id, county_id FROM tax_locale_county ORDER BY tax_locale_id, county_id;", func(rows *sql.Rows) error {
+		var localeId, countyId int
+		err := rows.Scan(&localeId, &countyId)
+		for _, id := range data.CountyLocales[countyId] {
+			if id == localeId {
+				return err
+			}
+		}
+		data.CountyLocales[countyId] = append(data.CountyLocales[countyId], localeId)
+		return err
+	})
+}
+
+// helper method to read the editions the state and local tables were loaded from. The tables are reloaded whole, so
+// the latest edition their rows name is taken.
+func readEditions(db *sql.DB, data *TaxData) error {
+	var err error
+	data.StateEdition, err = readEdition(db, "states")
+	if err != nil {
+		return err
+	}
+
+	data.LocalEdition, err = readEdition(db, "tax_locale")
+	return err
+}
+
+// helper method returning the latest edition the rows of a table were loaded from, nil if they name no source
+func readEdition(db *sql.DB, table string) (*Edition, error) {
+	query := fmt.Sprintf(`SELECT published_year, tax_year FROM source_manifest
+		WHERE manifest_id IN (SELECT manifest_id FROM %s) ORDER BY tax_year DESC, published_year DESC LIMIT 1;`, table)
+	var e Edition
+	err := db.QueryRow(query).Scan(&e.PublishedYear, &e.TaxYear)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,112 @@
+/* Logic to number the brackets of each filing status and validate the intervals they cover */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// positions of the bracket fields following the key columns of a bracket row
+const (
+	bracketOrdinal = 0
+	bracketRate    = 1
+	bracketLower   = 2
+	bracketUpper   = 3
+)
+
+// helper method to number the brackets of each key, the first keyLen columns of a row, in the order of the rows. A
+// bracket without an upper bound, given as empty, is bounded by the lower bound of the next bracket of its key, and
+// the last bracket of a key has no upper bound.
+func setBracketIntervals(brackets [][]string, keyLen int, nullString string) {
+	// position of the last bracket of each key
+	last := make(map[string]int)
+	for i, row := range brackets {
+		key := strings.Join(row[:keyLen], "|")
+		ordinal := 1
+		if j, ok := last[key]; ok {
+			prev := brackets[j][keyLen:]
+			ordinal, _ = strconv.Atoi(prev[bracketOrdinal])
+			ordinal++
+			if prev[bracketUpper] == "" {
+				prev[bracketUpper] = row[keyLen+bracketLower]
+			}
+		}
+		row[keyLen+bracketOrdinal] = strconv.Itoa(ordinal)
+		last[key] = i
+	}
+
+	for _, j := range last {
+		if brackets[j][keyLen+bracketUpper] == "" {
+			brackets[j][keyLen+bracketUpper] = nullString
+		}
+	}
+}
+
+// helper method to check the brackets of each key, the first keyLen columns of a row, cover contiguous intervals
+// that do not overlap and whose rates do not fall, returning a description of each failure. Only the last bracket
+// of a key may have no upper bound. Null rates and lower bounds, as for a state without an income tax, are taken
+// as zero as they are loaded.
+func validateBracketIntervals(brackets [][]string, keyLen int, nullString string) []string {
+	var failures []string
+	// the previous bracket of each key
+	prevs := make(map[string][]string)
+	var keys []string
+	for _, row := range brackets {
+		key := strings.Join(row[:keyLen], " ")
+		b := row[keyLen:]
+		fail := func(format string, args ...interface{}) {
+			failures = append(failures, fmt.Sprintf("bracket %s of %s: ", b[bracketOrdinal], key)+fmt.Sprintf(format, args...))
+		}
+
+		lower := parseBracketNumber(b[bracketLower], nullString)
+		if b[bracketUpper] != nullString && parseBracketNumber(b[bracketUpper], nullString) <= lower {
+			fail("the upper bound %s is not above the lower bound %s", b[bracketUpper], b[bracketLower])
+		}
+
+		prev, ok := prevs[key]
+		if !ok {
+			keys = append(keys, key)
+		} else {
+			if prev[bracketUpper] == nullString {
+				fail("it follows a bracket without an upper bound")
+			} else if prevUpper := parseBracketNumber(prev[bracketUpper], nullString); prevUpper > lower {
+				fail("it overlaps the previous bracket, which ends at %s", prev[bracketUpper])
+			} else if prevUpper < lower {
+				fail("there is a gap from the end of the previous bracket at %s", prev[bracketUpper])
+			}
+			if parseBracketNumber(b[bracketRate], nullString) < parseBracketNumber(prev[bracketRate], nullString) {
+				fail("the rate %s is below the rate %s of the previous bracket", b[bracketRate], prev[bracketRate])
+			}
+		}
+		prevs[key] = b
+	}
+
+	for _, key := range keys {
+		if prev := prevs[key]; prev[bracketUpper] != nullString {
+			failures = append(failures, fmt.Sprintf("bracket %s of %s: the last bracket has the upper bound %s", prev[bracketOrdinal], key, prev[bracketUpper]))
+		}
+	}
+
+	return failures
+}
+
+// helper method to parse a rate or bound of a bracket, zero if null
+func parseBracketNumber(n string, nullString string) float64 {
+	if n == nullString {
+		return 0
+	}
+
+	f, _ := strconv.ParseFloat(n, 64)
+	return f
+}
+
+// helper method returning an error listing the failed validations of brackets, nil if there are none
+func bracketValidationError(source string, failures []string) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("The %s brackets failed validation:\n%s", source, strings.Join(failures, "\n"))
+}
@@ -0,0 +1,137 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSetBracketIntervals(t *testing.T) {
+	// state, filing status, ordinal, rate, lower bound and upper bound of each bracket
+	brackets := [][]string{
+		{"1", "1", "", "0.02", "0", ""},
+		{"1", "2", "", "0.02", "0", ""},
+		{"1", "1", "", "0.04", "500", ""},
+		{"1", "2", "", "0.04", "1000", "2000"},
+		{"1", "1", "", "0.05", "3000", ""},
+		{"1", "2", "", "0.05", "2000", ""},
+		{"2", "1", "", "NONE", "NONE", ""},
+	}
+	want := [][]string{
+		{"1", "1", "1", "0.02", "0", "500"},
+		{"1", "2", "1", "0.02", "0", "1000"},
+		{"1", "1", "2", "0.04", "500", "3000"},
+		{"1", "2", "2", "0.04", "1000", "2000"},
+		{"1", "1", "3", "0.05", "3000", "NONE"},
+		{"1", "2", "3", "0.05", "2000", "NONE"},
+		{"2", "1", "1", "NONE", "NONE", "NONE"},
+	}
+
+	setBracketIntervals(brackets, 2, "NONE")
+	if !reflect.DeepEqual(brackets, want) {
+		t.Errorf("setBracketIntervals() = %q, want %q", brackets, want)
+	}
+}
+
+func TestValidateBracketIntervals(t *testing.T) {
+	tests := []struct {
+		name string
+		// state, filing status, ordinal, rate, lower bound and upper bound of each bracket
+		brackets [][]string
+		want     []string
+	}{
+		{
+			name: "contiguous",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "500"},
+				{"1", "2", "1", "0.02", "0", "1000"},
+				{"1", "1", "2", "0.04", "500", "NONE"},
+				{"1", "2", "2", "0.04", "1000", "NONE"},
+			},
+		},
+		{
+			name:     "no income tax",
+			brackets: [][]string{{"2", "1", "1", "NONE", "NONE", "NONE"}},
+		},
+		{
+			name: "upper bound not above lower bound",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "0"},
+				{"1", "1", "2", "0.04", "0", "NONE"},
+			},
+			want: []string{"bracket 1 of 1 1: the upper bound 0 is not above the lower bound 0"},
+		},
+		{
+			name: "follows bracket without upper bound",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "NONE"},
+				{"1", "1", "2", "0.04", "500", "NONE"},
+			},
+			want: []string{"bracket 2 of 1 1: it follows a bracket without an upper bound"},
+		},
+		{
+			name: "overlap",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "600"},
+				{"1", "1", "2", "0.04", "500", "NONE"},
+			},
+			want: []string{"bracket 2 of 1 1: it overlaps the previous bracket, which ends at 600"},
+		},
+		{
+			name: "gap",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "400"},
+				{"1", "1", "2", "0.04", "500", "NONE"},
+			},
+			want: []string{"bracket 2 of 1 1: there is a gap from the end of the previous bracket at 400"},
+		},
+		{
+			name: "falling rate",
+			brackets: [][]string{
+				{"1", "1", "1", "0.05", "0", "500"},
+				{"1", "1", "2", "0.02", "500", "NONE"},
+			},
+			want: []string{"bracket 2 of 1 1: the rate 0.02 is below the rate 0.05 of the previous bracket"},
+		},
+		{
+			name: "last bracket with upper bound",
+			brackets: [][]string{
+				{"1", "1", "1", "0.02", "0", "500"},
+				{"1", "1", "2", "0.04", "500", "1000"},
+			},
+			want: []string{"bracket 2 of 1 1: the last bracket has the upper bound 1000"},
+		},
+		{
+			name: "failures of several keys",
+			brackets: [][]string{
+				{"1", "1", "1", "0.05", "0", "400"},
+				{"1", "2", "1", "0.02", "0", "NONE"},
+				{"1", "1", "2", "0.02", "500", "NONE"},
+				{"1", "2", "2", "0.04", "1000", "NONE"},
+			},
+			want: []string{
+				"bracket 2 of 1 1: there is a gap from the end of the previous bracket at 400",
+				"bracket 2 of 1 1: the rate 0.02 is below the rate 0.05 of the previous bracket",
+				"bracket 2 of 1 2: it follows a bracket without an upper bound",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := validateBracketIntervals(tt.brackets, 2, "NONE")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("validateBracketIntervals(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBracketValidationError(t *testing.T) {
+	if err := bracketValidationError("state", nil); err != nil {
+		t.Errorf("bracketValidationError(state, nil) = %v, want nil", err)
+	}
+
+	failures := []string{"bracket 2 of 1 1: it follows a bracket without an upper bound", "bracket 2 of 1 2: the last bracket has the upper bound 1000"}
+	want := "The state brackets failed validation:\n" + failures[0] + "\n" + failures[1]
+	if err := bracketValidationError("state", failures); err == nil || err.Error() != want {
+		t.Errorf("bracketValidationError(state, %q) = %v, want %q", failures, err, want)
+	}
+}
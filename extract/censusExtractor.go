@@ -3,6 +3,8 @@
 package extract
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -18,6 +20,7 @@ import (
 // Census API constants
 const (
 	CENSUS_URL        = "https://api.census.gov/data/2019/acs/acs1"
+	CENSUS_YEAR       = "2019"
 	CENSUS_GET_PARAMS = "NAME,B01003_001E,B01001_002E,B01001_026E,B19013_001E,B25031_001E,C08536_001E"
 	CENSUS_GEO        = "COUNTY"
 	CENSUS_API_KEY    = "CENSUS_API_KEY"
@@ -28,22 +31,22 @@ const (
 var logger, _ = logging.GetLogger("file.log")
 
 // public method to retrieve census data from Census API for given
-// number of attempts to make on a failed response
-func GetCensusData(attempts int) ([][]string, error) {
+// number of attempts to make on a failed response, along with the
+// hex encoded SHA-256 checksum of the response
+func GetCensusData(attempts int) ([][]string, string, error) {
 	// retrieve the secret key from the environment
 	key := os.Getenv(CENSUS_API_KEY)
 
 	// format path with parameters and execute the request
-	params := "?get=" + CENSUS_GET_PARAMS + "&" +
-		"for=" + CENSUS_GEO + "&" +
-		"key=" + key
-	path := fmt.Sprintf(CENSUS_URL+"%s", params)
+	path := GetCensusQuery() + "&" + "key=" + key
 
 	body, err := executeGetRequest(path, attempts)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	sum := sha256.Sum256(body)
+
 	// format the response as a slice of string slices
 	var censusResp [][]string
 	json.Unmarshal(body, &censusResp)
@@ -51,7 +54,15 @@ func GetCensusData(attempts int) ([][]string, error) {
 	// run business logic to process the response
 	censusResp = processApiResponse(censusResp)
 
-	return censusResp, nil
+	return censusResp, hex.EncodeToString(sum[:]), nil
+}
+
+// public method returning the query made to the census API, without the key
+func GetCensusQuery() string {
+	params := "?get=" + CENSUS_GET_PARAMS + "&" +
+		"for=" + CENSUS_GEO
+
+	return fmt.Sprintf(CENSUS_URL+"%s", params)
 }
 
 // helper method to connect to the given Census API path in an increasing retry count
@@ -0,0 +1,91 @@
+/* Candidate index used to narrow the fuzzy matching of local tax jurisdictions to counties */
+
+package extract
+
+import (
+	"sort"
+	"strings"
+
+	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
+)
+
+// a name prepared once for all of the fuzzy scorers. The name is kept as given, as the scorers are run without
+// fuzzywuzzy's cleansing, so case and punctuation count as they did before the index.
+type matchName struct {
+	// name used by the plain, partial and token set ratios
+	name string
+	// tokens of the name sorted and joined, as fuzzy.TokenSortRatio sorts them without cleansing
+	sortedTokens string
+}
+
+// a county a tax jurisdiction may be linked to
+type countyCandidate struct {
+	// county id is the state id concated with the census county id
+	id   string
+	name matchName
+}
+
+// index of census counties bucketed by normalized state name. Built once per run
+// so each jurisdiction is only scored against the counties of its own state.
+type countyIndex struct {
+	byState map[string][]countyCandidate
+	// ids of all counties in the census data
+	ids map[string]bool
+}
+
+// build the candidate index from the processed census data
+func newCountyIndex(censusData [][]string) *countyIndex {
+	byState := make(map[string][]countyCandidate)
+	ids := make(map[string]bool)
+	for _, row := range censusData {
+		ids[row[7]+row[8]] = true
+		state := normalizeName(row[9])
+		byState[state] = append(byState[state], countyCandidate{
+			id:   row[7] + row[8],
+			name: newMatchName(row[0]),
+		})
+	}
+
+	return &countyIndex{byState: byState, ids: ids}
+}
+
+// return the counties within the given state
+func (c *countyIndex) candidates(state string) []countyCandidate {
+	return c.byState[normalizeName(state)]
+}
+
+// return whether the county id is in the census data
+func (c *countyIndex) hasCounty(id string) bool {
+	return c.ids[id]
+}
+
+// helper method to pre-process a name for the fuzzy scorers
+func newMatchName(s string) matchName {
+	tokens := strings.Fields(s)
+	sort.Strings(tokens)
+
+	return matchName{name: s, sortedTokens: strings.Join(tokens, " ")}
+}
+
+// helper method to lower case a name and collapse its whitespace
+func normalizeName(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// helper method returning the best score of all scorers between two prepared names. The scores are those of
+// fuzzy.PartialRatio, fuzzy.TokenSortRatio, fuzzy.TokenSetRatio and fuzzy.Ratio called on the names as given.
+func bestRatio(a, b matchName) int {
+	best := fuzzy.PartialRatio(a.name, b.name)
+	// fuzzy.TokenSortRatio without re-sorting the tokens of the names
+	if r := fuzzy.Ratio(a.sortedTokens, b.sortedTokens); r > best {
+		best = r
+	}
+	if r := fuzzy.TokenSetRatio(a.name, b.name); r > best {
+		best = r
+	}
+	if r := fuzzy.Ratio(a.name, b.name); r > best {
+		best = r
+	}
+
+	return best
+}
@@ -0,0 +1,207 @@
+package extract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
+)
+
+// county names of the census, with the punctuation and case of the census
+var testCountyNames = []string{
+	"St. Louis County", "St. Louis city", "Prince George's County", "Washington County", "Franklin County",
+	"Jefferson County", "Montgomery County", "Allegheny County", "Potter County", "Baltimore County",
+	"Baltimore city", "Kenton County", "Lucas County", "Wayne County", "Marion County", "Jackson County",
+	"De Kalb County", "DeKalb County", "Lake County", "Clark County", "Hamilton County", "Cuyahoga County",
+	"Philadelphia County", "Anne Arundel County", "Howard County", "St. Mary's County", "Queen Anne's County",
+}
+
+// states of the test census, each given every county name
+var testStates = []struct{ fips, name string }{
+	{"21", "Kentucky"}, {"24", "Maryland"}, {"29", "Missouri"}, {"39", "Ohio"}, {"42", "Pennsylvania"},
+}
+
+// census fixture and the local tax sheet it is matched against
+const (
+	testCensusFile    = "testdata/census_counties.json"
+	testLocalTaxFile  = "../data/Local_Income_Tax_Rates_2019.xlsx"
+	testLocalTaxSheet = "Local Income Tax Rates"
+)
+
+type testJurisdiction struct{ state, juris string }
+
+// jurisdictions of the local tax sheet to match
+var testJurisdictions = []testJurisdiction{
+	{"Missouri", "St. Louis"}, {"Missouri", "ST LOUIS"}, {"Maryland", "Prince George's"}, {"Maryland", "Prince Georges"},
+	{"Ohio", "Washington Twp"}, {"Ohio", "Columbus"}, {"Ohio", "Lucas"}, {"Pennsylvania", "Abbott Twp (Potter Co.)"},
+	{"Pennsylvania", "Philadelphia"}, {"Kentucky", "Kenton"}, {"Maryland", "Anne Arundel"}, {"Maryland", "St. Mary's"},
+	{"Ohio", "De Kalb"}, {"Maryland", "Baltimore City"}, {"Pennsylvania", "Allegheny"}, {"Kentucky", "Jefferson Co."},
+}
+
+// helper method building census rows of the test counties in each test state
+func newTestCensus() [][]string {
+	var census [][]string
+	for _, state := range testStates {
+		for i, county := range testCountyNames {
+			census = append(census, []string{county, "0", "0", "0", "0", "0", "0", state.fips, fmt.Sprintf("%03d", i+1), state.name})
+		}
+	}
+
+	return census
+}
+
+// the matching before the index: every census county is scored with the library scorers, re-parsing the
+// jurisdiction for each county, and only then is the county's state compared to the jurisdiction's
+func scanCountyId(censusData [][]string, state string, juris string, matchThresh int, nullString string) string {
+	max := 0
+	match := nullString
+	for _, row := range censusData {
+		if hint := countyHint.FindStringSubmatch(juris); hint != nil {
+			juris = hint[1]
+		}
+		for _, ratio := range []int{fuzzy.PartialRatio(row[0], juris), fuzzy.TokenSortRatio(row[0], juris),
+			fuzzy.TokenSetRatio(row[0], juris), fuzzy.Ratio(row[0], juris)} {
+			if ratio > matchThresh && ratio > max &&
+				strings.ToLower(strings.TrimSpace(state)) == strings.ToLower(strings.TrimSpace(row[9])) {
+				max = ratio
+				match = row[7] + row[8]
+			}
+		}
+	}
+
+	return match
+}
+
+// helper method reading the census fixture, a response of the census API query holding the counties of every state
+// of the local tax sheet. The names are those of the census, while the county codes are numbered in alphabetical
+// order, as only the names are matched. A saved response of the full query may be given by CENSUS_RESPONSE_FILE.
+func readTestCensus(tb testing.TB) [][]string {
+	path := testCensusFile
+	if file := os.Getenv("CENSUS_RESPONSE_FILE"); file != "" {
+		path = file
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("reading census response %s: %s", path, err)
+	}
+	var censusResp [][]string
+	if err := json.Unmarshal(body, &censusResp); err != nil {
+		tb.Fatalf("parsing census response %s: %s", path, err)
+	}
+
+	return processApiResponse(censusResp)
+}
+
+// helper method reading the state and jurisdiction of every row of the local tax sheet, as the extractor does
+func readSheetJurisdictions(tb testing.TB) []testJurisdiction {
+	records, err := sourcefileutils.OpenSourceTable(testLocalTaxFile, testLocalTaxSheet, localTaxColumns)
+	if err != nil {
+		tb.Fatalf("reading local tax sheet: %s", err)
+	}
+
+	var jurisdictions []testJurisdiction
+	state := ""
+	for _, record := range records {
+		if s := strings.TrimSpace(record.Get("state")); s != "" {
+			state = s
+			if ref, ok := lookupState(state); ok {
+				state = ref.name
+			}
+		}
+		if juris := strings.TrimSpace(record.Get("jurisdiction")); juris != "" {
+			jurisdictions = append(jurisdictions, testJurisdiction{state, record.Get("jurisdiction")})
+		}
+	}
+
+	return jurisdictions
+}
+
+func TestBestRatioMatchesLibrary(t *testing.T) {
+	for _, j := range testJurisdictions {
+		for _, county := range testCountyNames {
+			want := fuzzy.PartialRatio(county, j.juris)
+			for _, r := range []int{fuzzy.TokenSortRatio(county, j.juris), fuzzy.TokenSetRatio(county, j.juris), fuzzy.Ratio(county, j.juris)} {
+				if r > want {
+					want = r
+				}
+			}
+
+			if got := bestRatio(newMatchName(county), newMatchName(j.juris)); got != want {
+				t.Errorf("bestRatio(%q, %q) = %v, want %v", county, j.juris, got, want)
+			}
+		}
+	}
+}
+
+func TestCountyIndexMatchesScan(t *testing.T) {
+	for _, census := range [][][]string{newTestCensus(), readTestCensus(t)} {
+		index := newCountyIndex(census)
+		for _, j := range testJurisdictions {
+			want := scanCountyId(census, j.state, j.juris, 60, "NONE")
+			if got := getCountyId(index, j.state, j.juris, JURISDICTION_COUNTY, false, 60, "NONE"); got != want {
+				t.Errorf("getCountyId(%q, %q) = %s, want %s", j.state, j.juris, got, want)
+			}
+		}
+	}
+}
+
+func TestCountyIdByJurisdictionType(t *testing.T) {
+	index := newCountyIndex(newTestCensus())
+	tests := []struct {
+		state, juris, jurisType string
+		// whether place to county relationships are given
+		placesKnown bool
+		want        string
+	}{
+		// counties are matched by name
+		{"Ohio", "Washington County", JURISDICTION_COUNTY, true, "39004"},
+		{"Ohio", "Lucas", JURISDICTION_COUNTY, true, "39013"},
+		// places are not matched to the county sharing their name when their counties are known
+		{"Ohio", "Washington Twp", JURISDICTION_TOWNSHIP, true, "NONE"},
+		{"Ohio", "Hamilton city", JURISDICTION_CITY, true, "NONE"},
+		{"Kentucky", "Jefferson", JURISDICTION_OCCUPATIONAL_LICENSE, true, "NONE"},
+		// but are matched by name when they are not
+		{"Ohio", "Washington Twp", JURISDICTION_TOWNSHIP, false, "39004"},
+		{"Kentucky", "Jefferson", JURISDICTION_OCCUPATIONAL_LICENSE, false, "21006"},
+		// or when the sheet gives their county
+		{"Pennsylvania", "Abbott Twp (Potter Co.)", JURISDICTION_TOWNSHIP, true, "42009"},
+		// districts, which no place lists, are matched by name
+		{"Pennsylvania", "Allegheny County SD", JURISDICTION_SCHOOL_DISTRICT, true, "42008"},
+	}
+
+	for _, tt := range tests {
+		if got := getCountyId(index, tt.state, tt.juris, tt.jurisType, tt.placesKnown, 60, "NONE"); got != tt.want {
+			t.Errorf("getCountyId(%q, %q, %s, %v) = %s, want %s", tt.state, tt.juris, tt.jurisType, tt.placesKnown, got, tt.want)
+		}
+	}
+}
+
+// the benchmarks match every jurisdiction of the 2019 sheet by name, as the scan did
+func BenchmarkCountyMatchScan(b *testing.B) {
+	census := readTestCensus(b)
+	jurisdictions := readSheetJurisdictions(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, j := range jurisdictions {
+			scanCountyId(census, j.state, j.juris, 60, "NONE")
+		}
+	}
+}
+
+func BenchmarkCountyMatchIndex(b *testing.B) {
+	census := readTestCensus(b)
+	jurisdictions := readSheetJurisdictions(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// the index is built once per run, so its build is part of the cost
+		index := newCountyIndex(census)
+		for _, j := range jurisdictions {
+			getCountyId(index, j.state, j.juris, JURISDICTION_COUNTY, false, 60, "NONE")
+		}
+	}
+}
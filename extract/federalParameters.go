@@ -0,0 +1,161 @@
+/* Logic to extract the capital gains brackets and earned income tax credit parameters of the federal workbook */
+
+package extract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+// columns of the federal capital gains sheet. The rates sit in the column before the thresholds, under an empty
+// header cell.
+var federalCapitalGainsColumns = []sourcefileutils.Column{
+	{Name: "single", Labels: []string{"For Unmarried Individuals, Taxable Income Over", "Single"}, Required: true},
+	{Name: "married", Labels: []string{"For Married Individuals Filing Joint Returns, Taxable Income Over", "Married Filing Jointly"}, Required: true},
+	{Name: "head", Labels: []string{"For Heads of Households, Taxable Income Over", "Head of Household"}, Required: true},
+	{Name: "rate", RelativeTo: "single", Offset: -1, Required: true},
+}
+
+// columns of the federal earned income tax credit sheet. The parameter of each row sits in the column after the
+// filing status, under an empty header cell.
+var federalEitcColumns = []sourcefileutils.Column{
+	{Name: "status", Labels: []string{"Filing Status"}, Required: true},
+	{Name: "children_0", Labels: []string{"No Children", "Zero Children"}, Required: true},
+	{Name: "children_1", Labels: []string{"One Child"}, Required: true},
+	{Name: "children_2", Labels: []string{"Two Children"}, Required: true},
+	{Name: "children_3", Labels: []string{"Three or More Children", "Three Children"}, Required: true},
+	{Name: "parameter", RelativeTo: "status", Offset: 1, Required: true},
+}
+
+// the most qualifying children the earned income tax credit counts, the column of three or more children
+const EITC_MAX_CHILDREN = 3
+
+// parameters of the earned income tax credit in the order they are loaded, by the start of their label in the sheet
+var eitcParameters = []string{"income at max credit", "maximum credit", "phaseout begins", "phaseout ends"}
+
+// public method to build data structures for the federal long term capital gains brackets and earned income tax
+// credit from the given federal workbook, keyed by its tax year. Capital gains brackets are rows of the tax year,
+// filing status id, ordinal, rate, lower bound and upper bound, null for the top bracket. Earned income tax credit
+// parameters are rows of the tax year, filing status id, qualifying children, up to EITC_MAX_CHILDREN for that many
+// or more, earned income at the maximum credit, maximum credit, and the incomes the phaseout begins and ends at. An
+// error is returned if the capital gains brackets fail validation or a parameter of the credit is missing.
+func GetFederalParameterData(workbook sourcefileutils.Workbook, nullString string) ([][]string, [][]string, error) {
+	taxYear := fmt.Sprint(workbook.Year)
+
+	capitalGainsRecords, err := sourcefileutils.OpenSourceTable(workbook.FilePath, "Table 6", federalCapitalGainsColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eitcRecords, err := sourcefileutils.OpenSourceTable(workbook.FilePath, "Table 5", federalEitcColumns)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the sheet gives the threshold each rate starts at, a row per rate. Rows without a rate, such as the source
+	// note, are skipped.
+	var capitalGainsBrackets [][]string
+	for _, record := range capitalGainsRecords {
+		rate, err := parseutils.ParseRate(record.Get("rate"))
+		if err != nil {
+			continue
+		}
+
+		for _, col := range federalBracketStatuses {
+			capitalGainsBrackets = append(capitalGainsBrackets, []string{taxYear, col.status, "", rate.String(),
+				processDollarValue(record.Get(col.column), nullString), ""})
+		}
+	}
+
+	setBracketIntervals(capitalGainsBrackets, 2, nullString)
+	err = bracketValidationError("federal capital gains", validateBracketIntervals(capitalGainsBrackets, 2, nullString))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eitc, err := getEitcParameters(eitcRecords, taxYear, nullString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("The federal earned income tax credit sheet of %s %s", workbook.FilePath, err)
+	}
+
+	return capitalGainsBrackets, eitc, nil
+}
+
+// helper method to build the rows of the earned income tax credit from the records of its sheet. The sheet groups the
+// parameters by the filing statuses they apply to, i.e "Single or Head of Household", named in the first row of the
+// group.
+func getEitcParameters(records []sourcefileutils.Record, taxYear string, nullString string) ([][]string, error) {
+	// the parameters of each filing status, by the number of children and then the parameter
+	values := make(map[string]map[int]map[string]string)
+	var statuses []string
+	for _, record := range records {
+		if group := strings.ToLower(strings.TrimSpace(record.Get("status"))); group != "" {
+			statuses = nil
+			for _, status := range filingStatuses {
+				if strings.Contains(group, status[1]) {
+					statuses = append(statuses, status[0])
+				}
+			}
+		}
+
+		parameter := getEitcParameter(record.Get("parameter"))
+		if parameter == "" {
+			continue
+		}
+
+		for _, status := range statuses {
+			if values[status] == nil {
+				values[status] = make(map[int]map[string]string)
+			}
+			for children := 0; children <= EITC_MAX_CHILDREN; children++ {
+				if values[status][children] == nil {
+					values[status][children] = make(map[string]string)
+				}
+				values[status][children][parameter] = processDollarValue(record.Get("children_"+strconv.Itoa(children)), nullString)
+			}
+		}
+	}
+
+	// a row per filing status the sheet gives and number of children, in order of filing status id
+	var eitc [][]string
+	for _, status := range filingStatuses {
+		byChildren, ok := values[status[0]]
+		if !ok {
+			continue
+		}
+
+		for children := 0; children <= EITC_MAX_CHILDREN; children++ {
+			row := []string{taxYear, status[0], strconv.Itoa(children)}
+			for _, parameter := range eitcParameters {
+				value, ok := byChildren[children][parameter]
+				if !ok || value == nullString {
+					return nil, fmt.Errorf("has no %s for filing status %s with %v children", parameter, status[1], children)
+				}
+				row = append(row, value)
+			}
+			eitc = append(eitc, row)
+		}
+	}
+
+	if len(eitc) == 0 {
+		return nil, fmt.Errorf("names no filing status")
+	}
+
+	return eitc, nil
+}
+
+// helper method returning the parameter of the earned income tax credit a row label gives, empty if none
+func getEitcParameter(label string) string {
+	label = strings.ToLower(strings.Join(strings.Fields(label), " "))
+	for _, parameter := range eitcParameters {
+		if strings.HasPrefix(label, parameter) {
+			return parameter
+		}
+	}
+
+	return ""
+}
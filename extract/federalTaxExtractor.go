@@ -3,54 +3,120 @@
 package extract
 
 import (
-	"regexp"
+	"fmt"
+	"strconv"
 	"strings"
 
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
 	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
 )
 
-const FEDERAL_TAX_FILE = "data/2022-Federal-Income-Tax-Rates-and-Brackets-Tax-Foundation.xlsx"
+// columns of the federal bracket sheet
+var federalBracketColumns = []sourcefileutils.Column{
+	{Name: "rate", Labels: []string{"Rate"}, Required: true},
+	{Name: "single", Labels: []string{"For Unmarried Individuals", "Single"}, Required: true},
+	{Name: "married", Labels: []string{"For Married Individuals Filing Joint Returns", "Married Filing Jointly"}, Required: true},
+	{Name: "head", Labels: []string{"For Heads of Households", "Head of Household"}, Required: true},
+}
+
+// columns of the federal standard deduction sheet
+var federalDeductionColumns = []sourcefileutils.Column{
+	{Name: "status", Labels: []string{"Filing Status"}, Required: true},
+	{Name: "amount", Labels: []string{"Deduction Amount", "Standard Deduction"}, Required: true},
+}
+
+// filing status of each bracket column of the federal bracket sheet, in the order they are loaded
+var federalBracketStatuses = []struct {
+	column string
+	status string
+}{
+	{"single", SINGLE_STATUS},
+	{"married", MARRIED_JOINT_STATUS},
+	{"head", HEAD_OF_HOUSEHOLD_STATUS},
+}
+
+// filing statuses of the federal standard deductions, in the order they are loaded
+var federalDeductionStatuses = []string{SINGLE_STATUS, MARRIED_JOINT_STATUS, HEAD_OF_HOUSEHOLD_STATUS}
+
+// public method to build data structures for federal tax brackets and deductions from the given federal workbook,
+// keyed by its tax year. Brackets are rows of the tax year, filing status id, ordinal, rate, lower bound and upper
+// bound, null for the top bracket. Deductions are rows of the tax year, filing status id and deduction, located by
+// the filing status label of their row. An error is returned if the brackets of a filing status do not cover contiguous intervals with
+// rising rates, or if a filing status has no deduction in whole dollars.
+func GetFederalTaxData(workbook sourcefileutils.Workbook, nullString string) ([][]string, [][]string, error) {
+	taxYear := fmt.Sprint(workbook.Year)
 
-// public method to build data structures for federal tax brackets and exemptions
-func GetFederalTaxData() ([][]string, []string, error) {
 	// read in the federal individual sheets
-	federalBrackets, err := sourcefileutils.OpenExcelSheet(FEDERAL_TAX_FILE, "Table 1")
+	bracketRecords, err := sourcefileutils.OpenSourceTable(workbook.FilePath, "Table 1", federalBracketColumns)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	federalDeductions, err := sourcefileutils.OpenExcelSheet(FEDERAL_TAX_FILE, "Table 2")
+	deductionRecords, err := sourcefileutils.OpenSourceTable(workbook.FilePath, "Table 2", federalDeductionColumns)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	// pass over brackets and format data structure
-	federalBrackets = federalBrackets[2 : len(federalBrackets)-1]
-
-	for i, row := range federalBrackets {
-
-		row[0] = "0." + strings.TrimSuffix(strings.TrimSpace(row[0]), "%")
-		row[1] = processFederalBracket(row[1])
-		row[2] = processFederalBracket(row[2])
-		row[3] = processFederalBracket(row[3])
+	// pass over brackets and format data structure, a row per filing status. Rows without a rate, such as the
+	// source note, are skipped.
+	var federalBrackets [][]string
+	for _, record := range bracketRecords {
+		rate, err := parseutils.ParseRate(record.Get("rate"))
+		if err != nil {
+			continue
+		}
+
+		for _, col := range federalBracketStatuses {
+			lower, upper := processFederalBracket(record.Get(col.column), nullString)
+			federalBrackets = append(federalBrackets, []string{taxYear, col.status, "", rate.String(), lower, upper})
+		}
+	}
 
-		federalBrackets[i] = row
+	setBracketIntervals(federalBrackets, 2, nullString)
+	err = bracketValidationError("federal", validateBracketIntervals(federalBrackets, 2, nullString))
+	if err != nil {
+		return nil, nil, err
+	}
 
+	// map of filing status label, without footnote markers, to its deduction. Rows without an amount, such as the
+	// source note, are skipped.
+	deductions := make(map[string]parseutils.Decimal)
+	for _, record := range deductionRecords {
+		amount, err := parseutils.ParseAmount(record.Get("amount"))
+		if err != nil {
+			continue
+		}
+		label, _ := parseutils.SplitFootnoteMarkers(record.Get("status"))
+		deductions[strings.ToLower(strings.Join(strings.Fields(label), " "))] = amount
 	}
 
-	formattedFederalDeductions := []string{federalDeductions[2][1], federalDeductions[3][1], federalDeductions[4][1]}
+	var formattedFederalDeductions [][]string
+	for _, status := range federalDeductionStatuses {
+		amount, ok := deductions[getFilingStatusName(status)]
+		if !ok {
+			return nil, nil, fmt.Errorf("The federal standard deduction sheet of %s has no row for filing status %s", workbook.FilePath, getFilingStatusName(status))
+		}
+		deduction, ok := amount.Int64()
+		if !ok {
+			return nil, nil, fmt.Errorf("The federal standard deduction %s of filing status %s in %s is not a whole dollar amount", amount, getFilingStatusName(status), workbook.FilePath)
+		}
+		formattedFederalDeductions = append(formattedFederalDeductions, []string{taxYear, status, strconv.FormatInt(deduction, 10)})
+	}
 
 	return federalBrackets, formattedFederalDeductions, nil
 
 }
 
-// helper method with logic to federal bracket values
-func processFederalBracket(bracket string) string {
-
-	bracket = strings.TrimSpace(strings.Split(bracket, " to ")[0])
-
-	reg, _ := regexp.Compile("[^0-9]+")
-	rep := reg.ReplaceAllString(bracket, "")
+// helper method with logic to federal bracket values, returning the lower and upper bound of a bracket such as
+// "$10,275 to $41,775". The top bracket, such as "$539,900 or more", has a null upper bound, as does a bound that
+// cannot be parsed.
+func processFederalBracket(bracket string, nullString string) (string, string) {
+	bounds := strings.SplitN(strings.TrimSuffix(strings.TrimSpace(bracket), " or more"), " to ", 2)
+	lower := processDollarValue(bounds[0], nullString)
+	upper := nullString
+	if len(bounds) == 2 {
+		upper = processDollarValue(bounds[1], nullString)
+	}
 
-	return rep
+	return lower, upper
 }
@@ -0,0 +1,35 @@
+/* Logic defining the filing statuses the bracket and deduction data is keyed by */
+
+package extract
+
+// ids of the filing statuses, as loaded to the filing status table
+const (
+	SINGLE_STATUS            string = "1"
+	MARRIED_JOINT_STATUS     string = "2"
+	HEAD_OF_HOUSEHOLD_STATUS string = "3"
+	MARRIED_SEPARATE_STATUS  string = "4"
+)
+
+// filing statuses in order of id. The names are as the Tax Foundation labels them, in lower case.
+var filingStatuses = [][]string{
+	{SINGLE_STATUS, "single"},
+	{MARRIED_JOINT_STATUS, "married filing jointly"},
+	{HEAD_OF_HOUSEHOLD_STATUS, "head of household"},
+	{MARRIED_SEPARATE_STATUS, "married filing separately"},
+}
+
+// public method returning the rows of the filing status table, each the id and name of a filing status
+func GetFilingStatusData() [][]string {
+	return filingStatuses
+}
+
+// helper method returning the name of a filing status by id
+func getFilingStatusName(statusId string) string {
+	for _, status := range filingStatuses {
+		if status[0] == statusId {
+			return status[1]
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,157 @@
+/* Logic to read the bundled IRS parameters of each tax year that the federal workbook does not give */
+
+package extract
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// type of the IRS parameters source in the manifest, alongside the workbook types
+const IRS_PARAMETERS_SOURCE = "irs parameters"
+
+// license of the IRS parameters
+const IRS_LICENSE = "Publications of the Internal Revenue Service are works of the United States government and are not subject to copyright."
+
+// an entry of the parameters file, the parameters of a tax year. Amounts by filing status are keyed by the filing
+// status names of the filing status table.
+type irsParameters struct {
+	Year                int                `yaml:"year"`
+	Source              string             `yaml:"source"`
+	AdditionalDeduction map[string]float64 `yaml:"additionalDeduction"`
+	ChildTaxCredit      struct {
+		PerChild          float64            `yaml:"perChild"`
+		Refundable        float64            `yaml:"refundable"`
+		OtherDependent    float64            `yaml:"otherDependent"`
+		PhaseoutRate      float64            `yaml:"phaseoutRate"`
+		PhaseoutThreshold map[string]float64 `yaml:"phaseoutThreshold"`
+	} `yaml:"childTaxCredit"`
+	PayrollTaxes []struct {
+		Tax          string             `yaml:"tax"`
+		EmployeeRate float64            `yaml:"employeeRate"`
+		EmployerRate float64            `yaml:"employerRate"`
+		WageBase     *float64           `yaml:"wageBase"`
+		Threshold    map[string]float64 `yaml:"threshold"`
+	} `yaml:"payrollTaxes"`
+}
+
+// public method to build data structures for the additional standard deductions, child tax credit and payroll taxes
+// of the given tax year from the parameters file, keyed by the tax year. If the file has no entry for the year, the
+// latest earlier entry is used. Additional deductions are rows of the tax year, filing status id and the deduction
+// for each condition of age or blindness. Child tax credits are rows of the tax year, filing status id, credit per
+// child, refundable credit per child, credit per other dependent, phaseout threshold and phaseout rate. Payroll
+// taxes are rows of the tax year, filing status id, tax, employee rate, employer rate, wage base and threshold, the
+// last two null if the tax has none. An empty path reads no parameters.
+func GetIrsParameterData(filePath string, taxYear int, nullString string) ([][]string, [][]string, [][]string, error) {
+	if filePath == "" {
+		logger.Info("No IRS parameters file is configured, so no IRS parameters are loaded")
+		return nil, nil, nil, nil
+	}
+
+	p, err := readIrsParameters(filePath, taxYear)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	year := strconv.Itoa(taxYear)
+	var additionalDeductions [][]string
+	var childTaxCredits [][]string
+	var payrollTaxes [][]string
+	for _, status := range filingStatuses {
+		deduction, err := getStatusAmount(p.AdditionalDeduction, status[1], "additional deduction", p.Year)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		additionalDeductions = append(additionalDeductions, []string{year, status[0], deduction})
+
+		threshold, err := getStatusAmount(p.ChildTaxCredit.PhaseoutThreshold, status[1], "child tax credit phaseout threshold", p.Year)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		childTaxCredits = append(childTaxCredits, []string{year, status[0], formatParameter(p.ChildTaxCredit.PerChild),
+			formatParameter(p.ChildTaxCredit.Refundable), formatParameter(p.ChildTaxCredit.OtherDependent), threshold,
+			formatParameter(p.ChildTaxCredit.PhaseoutRate)})
+
+		for _, tax := range p.PayrollTaxes {
+			wageBase := nullString
+			if tax.WageBase != nil {
+				wageBase = formatParameter(*tax.WageBase)
+			}
+
+			threshold := nullString
+			if tax.Threshold != nil {
+				threshold, err = getStatusAmount(tax.Threshold, status[1], tax.Tax+" threshold", p.Year)
+				if err != nil {
+					return nil, nil, nil, err
+				}
+			}
+
+			payrollTaxes = append(payrollTaxes, []string{year, status[0], tax.Tax, formatParameter(tax.EmployeeRate),
+				formatParameter(tax.EmployerRate), wageBase, threshold})
+		}
+	}
+
+	return additionalDeductions, childTaxCredits, payrollTaxes, nil
+}
+
+// public method returning the manifest entry of the IRS parameters loaded for the given tax year by the given run,
+// with the checksum of the parameters file. The published year is the year of the entry used.
+func GetIrsParametersManifest(runId string, filePath string, taxYear int, checksum string) ([]string, error) {
+	p, err := readIrsParameters(filePath, taxYear)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{runId, IRS_PARAMETERS_SOURCE, p.Source, filePath, fmt.Sprint(p.Year), fmt.Sprint(taxYear), checksum, IRS_LICENSE}, nil
+}
+
+// helper method to read the entry of the parameters file for the given tax year, or the latest earlier entry if the
+// file has none for the year
+func readIrsParameters(filePath string, taxYear int) (irsParameters, error) {
+	yfile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return irsParameters{}, fmt.Errorf("There was an error reading in the IRS parameters file %s: %s", filePath, err)
+	}
+
+	var entries []irsParameters
+	err = yaml.Unmarshal(yfile, &entries)
+	if err != nil {
+		return irsParameters{}, fmt.Errorf("There was an error parsing the IRS parameters file %s: %s", filePath, err)
+	}
+
+	// latest year first
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Year > entries[j].Year
+	})
+	for _, p := range entries {
+		if p.Year > taxYear {
+			continue
+		}
+
+		if p.Year != taxYear {
+			logger.Warn("The IRS parameters file %s has no entry for %v, using the one for %v", filePath, taxYear, p.Year)
+		}
+		return p, nil
+	}
+
+	return irsParameters{}, fmt.Errorf("The IRS parameters file %s has no entry for %v or an earlier year", filePath, taxYear)
+}
+
+// helper method returning the amount of a filing status of an amount by filing status, as a string
+func getStatusAmount(amounts map[string]float64, status string, parameter string, year int) (string, error) {
+	amount, ok := amounts[status]
+	if !ok {
+		return "", fmt.Errorf("The %v IRS parameters have no %s for filing status %s", year, parameter, status)
+	}
+
+	return formatParameter(amount), nil
+}
+
+// helper method to format an amount or rate of the parameters file with as many digits as it needs
+func formatParameter(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -0,0 +1,192 @@
+/* Logic to link local tax jurisdictions that span several counties to each of their counties */
+
+package extract
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// a county a jurisdiction applies to, with the share of the jurisdiction's population living in it
+type countyLink struct {
+	countyId string
+	// share as a fraction, the null string if unknown
+	share string
+}
+
+// an entry of the overrides file, listing the counties of a jurisdiction
+type jurisdictionOverride struct {
+	State        string `yaml:"state"`
+	Jurisdiction string `yaml:"jurisdiction"`
+	Counties     []struct {
+		Fips  string   `yaml:"fips"`
+		Share *float64 `yaml:"share"`
+	} `yaml:"counties"`
+}
+
+// links of jurisdictions to several counties, keyed by the natural key of the jurisdiction or place
+type countyLinks map[string][]countyLink
+
+// legal and statistical area descriptions census place names end with, i.e "Kansas City city"
+var placeSuffixes = []string{" city", " town", " village", " borough", " boro", " township", " twp", " municipality", " cdp"}
+
+// helper method to read the overrides file listing the counties of jurisdictions. An empty path reads no overrides.
+func readJurisdictionOverrides(filePath string, nullString string) (countyLinks, error) {
+	links := make(countyLinks)
+	if filePath == "" {
+		return links, nil
+	}
+
+	yfile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading in the jurisdiction overrides file %s: %s", filePath, err)
+	}
+
+	var overrides []jurisdictionOverride
+	err = yaml.Unmarshal(yfile, &overrides)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error parsing the jurisdiction overrides file %s: %s", filePath, err)
+	}
+
+	for _, o := range overrides {
+		key := getTaxLocaleKey(o.State, o.Jurisdiction)
+		for _, c := range o.Counties {
+			share := nullString
+			if c.Share != nil {
+				share = strconv.FormatFloat(*c.Share, 'f', -1, 64)
+			}
+			links[key] = append(links[key], countyLink{countyId: c.Fips, share: share})
+		}
+	}
+
+	logger.Info("Loaded county overrides for %v jurisdictions from %s", len(links), filePath)
+
+	return links, nil
+}
+
+// helper method to read a place to county relationship file. The file is comma delimited with a header holding the
+// columns state (2 digit FIPS), county (3 digit FIPS), place (place name) and optionally share (fraction of the
+// place's population in the county). Links are keyed by the state id and normalized place name. An empty path
+// reads no relationships.
+func readPlaceCounties(filePath string, nullString string) (countyLinks, error) {
+	links := make(countyLinks)
+	if filePath == "" {
+		return links, nil
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading in the place to county file %s: %s", filePath, err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading the header of the place to county file %s: %s", filePath, err)
+	}
+
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"state", "county", "place"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("The place to county file %s is missing the %s column", filePath, required)
+		}
+	}
+	shareCol, hasShare := cols["share"]
+
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("There was an error reading the place to county file %s: %s", filePath, err)
+		}
+
+		share := nullString
+		if hasShare && strings.TrimSpace(row[shareCol]) != "" {
+			share = strings.TrimSpace(row[shareCol])
+		}
+
+		state := strings.TrimSpace(row[cols["state"]])
+		key := getPlaceKey(state, row[cols["place"]])
+		links[key] = append(links[key], countyLink{countyId: state + strings.TrimSpace(row[cols["county"]]), share: share})
+	}
+
+	logger.Info("Loaded county relationships for %v places from %s", len(links), filePath)
+
+	return links, nil
+}
+
+// helper method returning the key of a place, its state id and normalized name without the area description
+func getPlaceKey(stateId, place string) string {
+	place = normalizeName(countyHint.ReplaceAllString(footnoteMarker.ReplaceAllString(place, ""), " "))
+	for _, suffix := range placeSuffixes {
+		place = strings.TrimSuffix(place, suffix)
+	}
+
+	return stateId + ":" + place
+}
+
+// helper method returning the county links of a jurisdiction. Overrides take precedence over the place to county
+// relationships, which are only used for jurisdictions that are places. The fuzzy matched county is the single link
+// if neither is known. Counties not in the census data are dropped, as are repeated links to a county. The first
+// link returned is the one with the greatest population share, which is used as the jurisdiction's primary county.
+func getCountyLinks(index *countyIndex, overrides, places countyLinks, key, stateId, juris, jurisType, matchedCounty, nullString string) []countyLink {
+	links, ok := overrides[key]
+	if !ok && isPlaceJurisdiction(jurisType) {
+		links = places[getPlaceKey(stateId, juris)]
+	}
+
+	// keep the links to known counties
+	var known []countyLink
+	for _, link := range links {
+		if index.hasCounty(link.countyId) {
+			known = append(known, link)
+		} else {
+			logger.Warn("County %s linked to jurisdiction %s is not in the census data", link.countyId, key)
+		}
+	}
+	links = known
+
+	if len(links) == 0 {
+		if matchedCounty == nullString {
+			return nil
+		}
+		return []countyLink{{countyId: matchedCounty, share: nullString}}
+	}
+
+	// order by descending share, unknown shares last
+	sort.SliceStable(links, func(i, j int) bool {
+		si, ei := strconv.ParseFloat(links[i].share, 64)
+		sj, ej := strconv.ParseFloat(links[j].share, 64)
+		if ei != nil {
+			return false
+		} else if ej != nil {
+			return true
+		}
+		return si > sj
+	})
+
+	// a county is linked once, i.e when places sharing a key both lie in it, keeping its greatest share
+	seen := make(map[string]bool)
+	var unique []countyLink
+	for _, link := range links {
+		if !seen[link.countyId] {
+			seen[link.countyId] = true
+			unique = append(unique, link)
+		}
+	}
+
+	return unique
+}
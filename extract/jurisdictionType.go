@@ -0,0 +1,83 @@
+/* Classification of local tax jurisdictions by the type of government levying the tax */
+
+package extract
+
+import (
+	"regexp"
+	"strings"
+)
+
+// types of local tax jurisdictions
+const (
+	JURISDICTION_COUNTY               string = "county"
+	JURISDICTION_CITY                 string = "city"
+	JURISDICTION_MUNICIPALITY         string = "municipality"
+	JURISDICTION_TOWNSHIP             string = "township"
+	JURISDICTION_BOROUGH              string = "borough"
+	JURISDICTION_SCHOOL_DISTRICT      string = "school_district"
+	JURISDICTION_SPECIAL_DISTRICT     string = "special_district"
+	JURISDICTION_OCCUPATIONAL_LICENSE string = "occupational_license"
+)
+
+// the county a jurisdiction lies in when given by the sheet, i.e "Abbott Twp (Potter Co.)"
+var countyHint = regexp.MustCompile(`\s*\(([^()]*) Co\.\)\s*`)
+
+// abbreviations of school districts, i.e "Anna LSD" for a local school district in Ohio
+var schoolDistrictTokens = map[string]bool{"sd": true, "lsd": true, "csd": true, "evsd": true}
+
+// helper method to derive the type of a jurisdiction from its name and the conventions of its state
+func classifyJurisdiction(state, juris string) string {
+	name := normalizeName(countyHint.ReplaceAllString(footnoteMarker.ReplaceAllString(juris, ""), " "))
+	tokens := strings.Fields(name)
+	last := ""
+	if len(tokens) > 0 {
+		last = tokens[len(tokens)-1]
+	}
+	has := func(token string) bool {
+		for _, t := range tokens {
+			if t == token {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case schoolDistrictTokens[last] || strings.Contains(name, "school district") || strings.Contains(name, "school board"):
+		return JURISDICTION_SCHOOL_DISTRICT
+	// the type of a municipality is the last word of its name, i.e "District Twp"
+	case last == "township" || last == "twp":
+		return JURISDICTION_TOWNSHIP
+	case last == "borough" || last == "boro":
+		return JURISDICTION_BOROUGH
+	case has("district") || has("authority") || has("waterfront"):
+		return JURISDICTION_SPECIAL_DISTRICT
+	// Baltimore city is a county equivalent
+	case has("county") || name == "baltimore (city)":
+		return JURISDICTION_COUNTY
+	}
+
+	switch normalizeName(state) {
+	// Kentucky cities levy occupational license taxes rather than income taxes
+	case "kentucky":
+		return JURISDICTION_OCCUPATIONAL_LICENSE
+	// the earned income tax in Pennsylvania and municipal income tax in Ohio are levied by cities and villages
+	// alike, so a jurisdiction is a city only when named as one
+	case "pennsylvania", "ohio":
+		if last == "city" {
+			return JURISDICTION_CITY
+		}
+		return JURISDICTION_MUNICIPALITY
+	}
+
+	return JURISDICTION_CITY
+}
+
+// helper method returning whether a jurisdiction of the given type is a place, to be matched to the counties it lies in
+func isPlaceJurisdiction(jurisType string) bool {
+	switch jurisType {
+	case JURISDICTION_CITY, JURISDICTION_MUNICIPALITY, JURISDICTION_TOWNSHIP, JURISDICTION_BOROUGH, JURISDICTION_OCCUPATIONAL_LICENSE:
+		return true
+	}
+	return false
+}
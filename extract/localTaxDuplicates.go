@@ -0,0 +1,128 @@
+/* Logic to merge jurisdictions named on several rows of the local tax sheet into one record */
+
+package extract
+
+import (
+	"fmt"
+	"strings"
+)
+
+// positions of the fields of a processed local tax record, before it is keyed
+const (
+	recordJuris            = 0
+	recordCounty           = 1
+	recordType             = 2
+	recordResidentStart    = 3
+	recordNonresidentStart = recordResidentStart + localTaxComponentCount
+	localTaxComponentCount = 10
+)
+
+// a record of a jurisdiction keyed by its state and normalized name
+type keyedLocalTax struct {
+	key    string
+	state  string
+	record []string
+}
+
+// a description of a jurisdiction's tax that differs between two rows of the same name, which are then kept as
+// distinct jurisdictions
+type mergeConflict struct {
+	key string
+	// key the conflicting row is kept under
+	distinctKey string
+	field       string
+	kept        string
+	other       string
+}
+
+// helper method to merge the records of rows naming the same jurisdiction, returning one record per jurisdiction in
+// the order the jurisdictions first appear along with the conflicts found. A repeated row is merged into the first
+// row of its name it does not conflict with, see mergeLocalTaxRecord. A row conflicting with every row of its name is
+// a distinct jurisdiction of the same name, i.e the Ohio villages of Oakwood taxing 2.50% and 1.00%. The county the
+// sheet gives is part of the name, and the type is derived from the name, so such rows are told apart by their order:
+// the second jurisdiction of a key is keyed "<key> (2)", and so on.
+func mergeLocalTaxRecords(records []keyedLocalTax, nullString string) ([]keyedLocalTax, []mergeConflict) {
+	var merged []keyedLocalTax
+	var conflicts []mergeConflict
+	// positions of the distinct jurisdictions of each key
+	positions := make(map[string][]int)
+	repeated := 0
+	for _, r := range records {
+		var rowConflicts []mergeConflict
+		for _, pos := range positions[r.key] {
+			rowConflicts = findMergeConflicts(merged[pos], r)
+			if len(rowConflicts) == 0 {
+				mergeLocalTaxRecord(merged[pos], r, nullString)
+				repeated++
+				break
+			}
+		}
+		if len(positions[r.key]) > 0 && len(rowConflicts) == 0 {
+			continue
+		}
+
+		key := r.key
+		if n := len(positions[key]); n > 0 {
+			r.key = fmt.Sprintf("%s (%v)", key, n+1)
+			// the conflicts reported are those with the last jurisdiction of the key
+			for _, c := range rowConflicts {
+				c.distinctKey = r.key
+				conflicts = append(conflicts, c)
+			}
+		}
+		positions[key] = append(positions[key], len(merged))
+		merged = append(merged, r)
+	}
+
+	if repeated > 0 {
+		logger.Info("Merged %v repeated rows of the local tax sheet into the records of their jurisdictions", repeated)
+	}
+
+	return merged, conflicts
+}
+
+// helper method returning the descriptions two rows of the same name give differently. Rows are complementary when
+// one of them leaves a description empty, i.e a row giving only the resident tax and another giving only the
+// nonresident tax, which is not a conflict.
+func findMergeConflicts(kept, other keyedLocalTax) []mergeConflict {
+	var conflicts []mergeConflict
+	for _, part := range []struct {
+		field string
+		start int
+	}{{"resident", recordResidentStart}, {"nonresident", recordNonresidentStart}} {
+		keptDesc := kept.record[part.start]
+		otherDesc := other.record[part.start]
+		if strings.TrimSpace(keptDesc) != "" && strings.TrimSpace(otherDesc) != "" && normalizeName(keptDesc) != normalizeName(otherDesc) {
+			conflicts = append(conflicts, mergeConflict{key: kept.key, field: part.field, kept: keptDesc, other: otherDesc})
+		}
+	}
+
+	return conflicts
+}
+
+// helper method to merge the record of a repeated row into the record of the jurisdiction's first row, which it does
+// not conflict with. A description given by only the repeated row is taken, as is a county matched for only the
+// repeated row.
+func mergeLocalTaxRecord(kept, other keyedLocalTax, nullString string) {
+	for _, start := range []int{recordResidentStart, recordNonresidentStart} {
+		if strings.TrimSpace(kept.record[start]) == "" {
+			copy(kept.record[start:start+localTaxComponentCount], other.record[start:start+localTaxComponentCount])
+		}
+	}
+
+	if kept.record[recordCounty] == nullString {
+		kept.record[recordCounty] = other.record[recordCounty]
+	}
+}
+
+// helper method to report the conflicting descriptions of jurisdictions sharing a name
+func reportMergeConflicts(conflicts []mergeConflict) {
+	if len(conflicts) == 0 {
+		return
+	}
+
+	logger.Warn("%v descriptions of rows sharing the name of a jurisdiction conflict, so the rows are kept as distinct jurisdictions:", len(conflicts))
+	for _, c := range conflicts {
+		logger.Warn("%s tax of %s: %q, and %q of %s", c.field, c.key, c.kept, c.other, c.distinctKey)
+	}
+}
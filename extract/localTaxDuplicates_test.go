@@ -0,0 +1,92 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+// helper method building a keyed record of a jurisdiction with the given county and descriptions. Only the
+// description of each part is set, its components are left empty.
+func newTestLocalTax(key, county, resident, nonresident string) keyedLocalTax {
+	record := make([]string, recordNonresidentStart+localTaxComponentCount)
+	record[recordJuris] = key
+	record[recordCounty] = county
+	record[recordType] = JURISDICTION_MUNICIPALITY
+	record[recordResidentStart] = resident
+	record[recordNonresidentStart] = nonresident
+
+	return keyedLocalTax{key: key, state: "Ohio", record: record}
+}
+
+func TestMergeLocalTaxRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		records []keyedLocalTax
+		// key, county and descriptions of each merged record
+		want      [][4]string
+		conflicts []mergeConflict
+	}{
+		{
+			name: "repeated row",
+			records: []keyedLocalTax{
+				newTestLocalTax("kentucky:woodford county", "21239", "1.50%", "0.00%"),
+				newTestLocalTax("kentucky:woodford county", "21239", "1.50%", " 0.00% "),
+			},
+			want: [][4]string{{"kentucky:woodford county", "21239", "1.50%", "0.00%"}},
+		},
+		{
+			name: "fill from other row",
+			records: []keyedLocalTax{
+				newTestLocalTax("ohio:akron", "NONE", "2.50%", ""),
+				newTestLocalTax("ohio:akron", "39153", "", "2.50%"),
+			},
+			want: [][4]string{{"ohio:akron", "39153", "2.50%", "2.50%"}},
+		},
+		{
+			name: "conflict",
+			records: []keyedLocalTax{
+				newTestLocalTax("ohio:oakwood", "39113", "2.50%", "2.50%"),
+				newTestLocalTax("ohio:oakwood", "NONE", "1.00%", ""),
+			},
+			want: [][4]string{{"ohio:oakwood", "39113", "2.50%", "2.50%"}, {"ohio:oakwood (2)", "NONE", "1.00%", ""}},
+			conflicts: []mergeConflict{
+				{key: "ohio:oakwood", distinctKey: "ohio:oakwood (2)", field: "resident", kept: "2.50%", other: "1.00%"},
+			},
+		},
+		{
+			name: "distinct jurisdictions",
+			records: []keyedLocalTax{
+				newTestLocalTax("ohio:oakwood", "39113", "2.50%", "2.50%"),
+				newTestLocalTax("ohio:oakwood", "39113", "2.50%", "2.50%"),
+				newTestLocalTax("ohio:oakwood", "39125", "1.00%", "1.00%"),
+				newTestLocalTax("ohio:oakwood", "NONE", "1.00%", ""),
+				newTestLocalTax("ohio:oakwood", "NONE", "2.00%", "2.00%"),
+			},
+			want: [][4]string{
+				{"ohio:oakwood", "39113", "2.50%", "2.50%"},
+				{"ohio:oakwood (2)", "39125", "1.00%", "1.00%"},
+				{"ohio:oakwood (3)", "NONE", "2.00%", "2.00%"},
+			},
+			conflicts: []mergeConflict{
+				{key: "ohio:oakwood", distinctKey: "ohio:oakwood (2)", field: "resident", kept: "2.50%", other: "1.00%"},
+				{key: "ohio:oakwood", distinctKey: "ohio:oakwood (2)", field: "nonresident", kept: "2.50%", other: "1.00%"},
+				{key: "ohio:oakwood (2)", distinctKey: "ohio:oakwood (3)", field: "resident", kept: "1.00%", other: "2.00%"},
+				{key: "ohio:oakwood (2)", distinctKey: "ohio:oakwood (3)", field: "nonresident", kept: "1.00%", other: "2.00%"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		merged, conflicts := mergeLocalTaxRecords(tt.records, "NONE")
+		var got [][4]string
+		for _, m := range merged {
+			got = append(got, [4]string{m.key, m.record[recordCounty], m.record[recordResidentStart], m.record[recordNonresidentStart]})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: mergeLocalTaxRecords() = %v, want %v", tt.name, got, tt.want)
+		}
+		if !reflect.DeepEqual(conflicts, tt.conflicts) {
+			t.Errorf("%s: mergeLocalTaxRecords() conflicts = %+v, want %+v", tt.name, conflicts, tt.conflicts)
+		}
+	}
+}
@@ -3,182 +3,316 @@
 package extract
 
 import (
+	"errors"
+	"fmt"
+	"hash/fnv"
 	"math"
+	"regexp"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
-	"sync/atomic"
-
-	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
+	"time"
 
 	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
 )
 
-const LOCAL_TAX_FILE = "data/Local_Income_Tax_Rates_2019.xlsx"
+// columns of the local tax sheet
+var localTaxColumns = []sourcefileutils.Column{
+	{Name: "state", Labels: []string{"State"}, Required: true},
+	{Name: "jurisdiction", Labels: []string{"Taxing Jurisdiction", "Jurisdiction"}, Required: true},
+	{Name: "resident", Labels: []string{"Resident"}, Required: true},
+	{Name: "nonresident", Labels: []string{"Nonresident", "Non-resident"}, Required: true},
+}
+
+// number of pay periods in a year of each pay frequency, used to annualize per pay period fees
+var payPeriodsPerYear = map[string]int{"weekly": 52, "biweekly": 26, "semimonthly": 24, "monthly": 12}
+
+// trailing footnote marker on a jurisdiction name, i.e "San Francisco (a)"
+var footnoteMarker = regexp.MustCompile(`\s*\([a-z]{1,2}\)\s*$`)
+
+// a row of the local tax sheet to be processed by a worker, along with its position in the sheet
+type localTaxJob struct {
+	pos         int
+	state       string
+	juris       string
+	resident    string
+	nonresident string
+}
 
-// helper method to format 2D array holding local tax data
-func GetLocalTaxData(censusData [][]string, matchThresh int, nullString string) ([][]string, error) {
+// helper method to format 2D arrays holding local tax data from the given local tax workbook and the links of each jurisdiction to the counties it
+// applies to. Rows are processed by a pool of the given number of workers, or GOMAXPROCS workers if not positive,
+// and are returned in the order of the sheet. Jurisdictions spanning several counties are linked to each of them
+// using the overrides and place to county files, if given. Flat fees are annualized assuming the given pay frequency.
+// Jurisdictions already loaded keep the id given in loadedIds by their key, and an error is returned if a new
+// jurisdiction's id collides with that of another.
+func GetLocalTaxData(workbook sourcefileutils.Workbook, censusData [][]string, matchThresh int, workers int, payFrequency string, overridesFile string, placeCountyFile string, loadedIds map[string]int32, nullString string) ([][]string, [][]string, error) {
+
+	payPeriods, ok := payPeriodsPerYear[strings.ToLower(payFrequency)]
+	if !ok {
+		return nil, nil, fmt.Errorf("Unknown pay frequency %s, expected one of weekly, biweekly, semimonthly or monthly", payFrequency)
+	}
 
 	// get data from sourcefileutils
-	localTaxData, err := sourcefileutils.OpenExcelSheet(LOCAL_TAX_FILE, "Local Income Tax Rates")
+	localTaxData, err := sourcefileutils.OpenSourceTable(workbook.FilePath, workbook.Sheet, localTaxColumns)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	// iterate over the data and format each row
-	// keep track of the current state
-	state := ""
-	// number of unmatched
-	var unmatched uint64
-	// processed data to return
-	var processedLocalTaxData [][]string
-	// wait group to manage syncing go routines
+	// the known counties of jurisdictions spanning several counties
+	overrides, err := readJurisdictionOverrides(overridesFile, nullString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	places, err := readPlaceCounties(placeCountyFile, nullString)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	// processed records, written back by the position of their source row so output order is deterministic
+	results := make([][]string, len(localTaxData))
+	// errors parsing the descriptions of each row, reported once all rows are processed
+	parseErrs := make([][]error, len(localTaxData))
+	// index of counties by state, built once for all jurisdictions
+	index := newCountyIndex(censusData)
+	start := time.Now()
+	// wait group to manage syncing the workers
 	var wg sync.WaitGroup
-	// mutex for writing to data structure to return
-	var mt sync.Mutex
-	for i, row := range localTaxData {
-		// skip empty rows and first row
-		if len(row) < 3 || i == 0 {
+	jobs := make(chan localTaxJob)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				// the type of jurisdiction decides how it is matched to counties
+				jurisType := classifyJurisdiction(job.state, job.juris)
+
+				// use fuzzy matching to retrieve a county id
+				county_id := getCountyId(index, job.state, job.juris, jurisType, len(places) > 0, matchThresh, nullString)
+
+				// get the components of resident tax description
+				resident_attr, residentErr := getLocalTaxComponents(job.resident, payPeriods, nullString)
+
+				// get the components of nonresident tax description
+				nonresident_attr, nonresidentErr := getLocalTaxComponents(job.nonresident, payPeriods, nullString)
+
+				for _, err := range []error{residentErr, nonresidentErr} {
+					if err != nil {
+						parseErrs[job.pos] = append(parseErrs[job.pos], err)
+					}
+				}
+
+				// each worker writes to a distinct position, so no lock is needed
+				results[job.pos] = append(append([]string{job.juris, county_id, jurisType}, resident_attr...), nonresident_attr...)
+			}
+		}()
+	}
+
+	// iterate over the data and send each row to the workers
+	// keep track of the current state, and the state of each row for its key
+	state := ""
+	states := make([]string, len(localTaxData))
+	for i, record := range localTaxData {
+		// skip empty rows and footnotes, which give no taxes
+		if strings.TrimSpace(record.Get("jurisdiction")) == "" ||
+			(strings.TrimSpace(record.Get("resident")) == "" && strings.TrimSpace(record.Get("nonresident")) == "") {
 			continue
 		}
 		// set the state if this record includes state. This way counties with same name in other state are not matched.
-		if len(strings.TrimSpace(row[0])) != 0 {
-			state = row[0]
+		if len(strings.TrimSpace(record.Get("state"))) != 0 {
+			state = record.Get("state")
+			// a state given by an abbreviation, i.e "Pa.", is named as the census names it
+			if ref, ok := lookupState(state); ok {
+				state = ref.name
+			}
 		}
+		states[i] = state
 
-		juris := row[1]
-		resident := row[2]
-		nonresident := row[3]
+		jobs <- localTaxJob{pos: i, state: state, juris: record.Get("jurisdiction"), resident: record.Get("resident"), nonresident: record.Get("nonresident")}
+	}
 
-		// state can be modified through other go routines advancing through the loop,
-		// so make a copy for this loop to use
-		state := state
+	// all workers must finish before returining the data
+	close(jobs)
+	wg.Wait()
 
-		// process record in a go routine to exploit paralellism
-		// add one to the wait for this record
-		wg.Add(1)
+	// drop the positions of skipped rows, key the remaining records by state and jurisdiction
+	var keyed []keyedLocalTax
+	for i, record := range results {
+		if record == nil {
+			continue
+		}
+		keyed = append(keyed, keyedLocalTax{key: getTaxLocaleKey(states[i], record[recordJuris]), state: states[i], record: record})
+	}
 
-		go func() {
-			// use fuzzy matching to retrieve a county id
-			county_id := getCountyId(censusData, state, juris, matchThresh, nullString)
+	// the key is unique in the table, so rows naming the same jurisdiction are merged into one record
+	keyed, conflicts := mergeLocalTaxRecords(keyed, nullString)
 
-			// increment unmatched atomically
-			if county_id == nullString {
-				atomic.AddUint64(&unmatched, 1)
-			}
+	var processedLocalTaxData [][]string
+	var localTaxCounties [][]string
+	// number of unmatched
+	unmatched := 0
+	// ids taken by loaded jurisdictions, which new ids must not collide with
+	usedIds := make(map[int32]string)
+	for key, id := range loadedIds {
+		usedIds[id] = key
+	}
+	for _, k := range keyed {
+		record := k.record
+		id, err := getTaxLocaleId(k.key, loadedIds, usedIds)
+		if err != nil {
+			return nil, nil, err
+		}
 
-			// get the components of resident tax description
-			resident_attr := getLocalTaxComponents(resident, nullString)
+		// jurisdictions keep their state even when no county is matched
+		stateId := nullString
+		if ref, ok := lookupState(k.state); ok {
+			stateId = ref.fips
+		} else {
+			logger.Warn("State %s of jurisdiction %s is not in the state reference", k.state, record[recordJuris])
+		}
 
-			// get the components of nonresident tax description
-			nonresident_attr := getLocalTaxComponents(nonresident, nullString)
+		// link the jurisdiction to each of its counties, the first is its primary county
+		links := getCountyLinks(index, overrides, places, k.key, stateId, record[recordJuris], record[recordType], record[recordCounty], nullString)
+		if len(links) == 0 {
+			unmatched++
+		} else {
+			record[recordCounty] = links[0].countyId
+		}
+		for _, link := range links {
+			localTaxCounties = append(localTaxCounties, []string{fmt.Sprint(id), link.countyId, link.share})
+		}
 
-			processedRecord := append(append([]string{juris, county_id}, resident_attr...), nonresident_attr...)
-			mt.Lock()
-			processedLocalTaxData = append(processedLocalTaxData, processedRecord)
-			mt.Unlock()
-			// decrement the counter
-			wg.Done()
-		}()
+		processedLocalTaxData = append(processedLocalTaxData, append([]string{fmt.Sprint(id), k.key, stateId}, record...))
+	}
+	reportMergeConflicts(conflicts)
+	reportUnparsedDescs(parseErrs)
+	logger.Info("Matched %v local tax jurisdictions to counties in %s using %v workers", len(processedLocalTaxData), time.Since(start), workers)
 
+	if unmatched > 0 {
+		logger.Warn("%v local tax jurisdictions were not able to be matched to a county out of %v. (%v %s).", unmatched, len(processedLocalTaxData), math.Round(float64(unmatched)/float64(len(processedLocalTaxData))*100), "%")
 	}
 
-	// all go routines must finish before returining the data
-	wg.Wait()
+	return processedLocalTaxData, localTaxCounties, nil
 
-	if unmatched > 0 {
-		logger.Warn("%v local tax jurisdictions were not able to be fuzzy matched out of %v. (%v %s).", unmatched, len(processedLocalTaxData), math.Round(float64(unmatched)/float64(len(processedLocalTaxData))*100), "%")
+}
+
+// helper method returning the natural key of a jurisdiction, its normalized state and name. Footnote
+// markers such as "(a)" are dropped so the key does not change between editions of the sheet.
+func getTaxLocaleKey(state, juris string) string {
+	juris = footnoteMarker.ReplaceAllString(juris, "")
+	return normalizeName(state) + ":" + normalizeName(juris)
+}
+
+// helper method returning the id of a jurisdiction by its natural key. A jurisdiction already loaded keeps its id,
+// otherwise the id is derived from a hash of the key. A hash colliding with the id of another key is an error rather
+// than probed past, as a probed id would depend on the order of the sheet.
+func getTaxLocaleId(key string, loadedIds map[string]int32, usedIds map[int32]string) (int32, error) {
+	if id, ok := loadedIds[key]; ok {
+		return id, nil
 	}
 
-	return processedLocalTaxData, nil
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	id := int32(h.Sum32() & math.MaxInt32)
+
+	if other, ok := usedIds[id]; ok && other != key {
+		return 0, fmt.Errorf("Tax locale key %s hashes to id %v, which is taken by %s", key, id, other)
+	}
+	usedIds[id] = key
 
+	return id, nil
 }
 
-// helper method to decompose a description of local taxes into the component attributes
-func getLocalTaxComponents(taxDesc, nullString string) []string {
-	rate := nullString
-	month := nullString
-	year := nullString
-	payPeriod := nullString
-	stateLiability := nullString
-
-	if strings.Contains(taxDesc, "-") {
-		logger.Warn("%s contains range, cannot parse componenets", taxDesc)
-	} else if strings.Contains(taxDesc, "month") {
-		// split by delimiter, value is the first part, there is a leading dollar sign so get second character
-		month = string(strings.TrimSpace(strings.Split(taxDesc, " / ")[0])[1:])
-	} else if strings.Contains(taxDesc, "year") {
-		// split by delimiter, value is the first part, there is a leading dollar sign so get second character
-		year = string(strings.TrimSpace(strings.Split(taxDesc, " a ")[0])[1:])
-	} else if strings.Contains(taxDesc, "per pay period") {
-		// split by delimiter, value is the first part, there is a leading dollar sign so get second character
-		payPeriod = string(strings.TrimSpace(strings.Split(taxDesc, " per pay ")[0])[1:])
-	} else if strings.Contains(taxDesc, "of state liability") || strings.Contains(taxDesc, "of state tax") {
-		// split by delimiter, value is the first part, remove the % sign
-		stateLiability = strings.TrimSuffix(string(strings.TrimSpace(strings.Split(taxDesc, " of state ")[0])), "%")
-	} else if strings.Contains(taxDesc, "+") {
-		// first portion is rate, second is yearly fee
-		splitDesc := strings.Split(taxDesc, " + ")
-		rate = strings.TrimSpace(strings.TrimSuffix(splitDesc[0], "%"))
-		// there is a value for the yearly fee in the second part
-		year = strings.Split(splitDesc[1], " ")[0][1:]
-	} else if strings.Contains(taxDesc, "no LST") {
-		// first portion has the rate
-		rate = strings.TrimSpace(strings.TrimSuffix(strings.Split(taxDesc, " (")[0], "%"))
-	} else if strings.Contains(taxDesc, "%") && !strings.Contains(taxDesc, "dividends") && !strings.Contains(taxDesc, "to") {
-		// else is rate, remove the % at the end
-		rate = strings.TrimSuffix(strings.TrimSpace(taxDesc), "%")
-	}
-
-	return []string{taxDesc, rate, month, year, payPeriod, stateLiability}
+// helper method to decompose a description of local taxes into the component attributes, along with the yearly total
+// of its flat fees given the number of pay periods in a year. If the description cannot be fully parsed all
+// components are null and the parse error is returned.
+func getLocalTaxComponents(taxDesc string, payPeriods int, nullString string) ([]string, error) {
+	components := []string{taxDesc, nullString, nullString, nullString, nullString, nullString, nullString, nullString, nullString, nullString}
+
+	parsed, err := parseLocalTaxDesc(taxDesc)
+	if err != nil {
+		return components, err
+	}
+
+	for i, v := range []string{parsed.Rate, parsed.MonthFee, parsed.YearFee, parsed.PayPeriodFee,
+		parsed.AnnualFlatFee(payPeriods), parsed.StateLiability, parsed.MinRate, parsed.MaxRate, parsed.Note} {
+		if v != "" {
+			components[i+1] = v
+		}
+	}
+
+	return components, nil
 }
 
-// helper method that uses fuzzy matching to return the county id that matches the tax jurisdiction
-func getCountyId(censusData [][]string, state string, juris string, matchThresh int, nullString string) string {
-	// pass over census data, return id of greatest match above threshold
-	max := 0
-	match := nullString
-	for _, row := range censusData {
-		rowCounty := row[0]
-		// parse the county portion of Juris if it exists to increase matches
-		if strings.Contains(juris, "Co.") {
-			split := strings.Split(juris, " (")
-			if len(split) > 1 {
-				juris = strings.TrimSuffix(split[1], ")")
+// helper method to report every description that could not be fully parsed, with the number of times it appears
+func reportUnparsedDescs(parseErrs [][]error) {
+	counts := make(map[string]int)
+	reasons := make(map[string]string)
+	total := 0
+	for _, errs := range parseErrs {
+		for _, err := range errs {
+			var descErr *DescParseError
+			if !errors.As(err, &descErr) {
+				continue
 			}
+			counts[descErr.Desc]++
+			reasons[descErr.Desc] = descErr.Error()
+			total++
 		}
-		// try to match each ratio in order of decreasing liklihood of a match. County
-		// id is the census data's state field concated with county, as a census county id is
-		// only unique witihn a state
-		ratio := fuzzy.PartialRatio(rowCounty, juris)
-		if isNewFuzzyMatch(ratio, matchThresh, max, state, row[9]) {
-			max = ratio
-			match = row[7] + row[8]
-		}
+	}
 
-		ratio = fuzzy.TokenSortRatio(rowCounty, juris)
-		if isNewFuzzyMatch(ratio, matchThresh, max, state, row[9]) {
-			max = ratio
-			match = row[7] + row[8]
-		}
+	if total == 0 {
+		return
+	}
 
-		ratio = fuzzy.TokenSetRatio(rowCounty, juris)
-		if isNewFuzzyMatch(ratio, matchThresh, max, state, row[9]) {
-			max = ratio
-			match = row[7] + row[8]
+	// most frequent first, then by description so the report is stable
+	descs := make([]string, 0, len(counts))
+	for desc := range counts {
+		descs = append(descs, desc)
+	}
+	sort.Slice(descs, func(i, j int) bool {
+		if counts[descs[i]] != counts[descs[j]] {
+			return counts[descs[i]] > counts[descs[j]]
 		}
+		return descs[i] < descs[j]
+	})
+
+	logger.Warn("%v local tax descriptions (%v distinct) could not be fully parsed:", total, len(descs))
+	for _, desc := range descs {
+		logger.Warn("%v x %s", counts[desc], reasons[desc])
+	}
+}
+
+// helper method that uses fuzzy matching to return the county id that matches the tax jurisdiction. Given place to
+// county relationships, only the names of counties are matched: a place is not fuzzy matched by its own name, as it is
+// not that of its county, i.e "Washington Twp" does not lie in "Washington County". Places are then linked to their
+// counties by the overrides and place to county files, or by the county the sheet gives. Without place to county
+// relationships, places are matched by name like any other jurisdiction, so they are not left without a county.
+func getCountyId(index *countyIndex, state string, juris string, jurisType string, placesKnown bool, matchThresh int, nullString string) string {
+	// match the county the sheet gives for the jurisdiction if it exists, i.e "Potter" for "Abbott Twp (Potter Co.)"
+	if hint := countyHint.FindStringSubmatch(juris); hint != nil {
+		juris = hint[1]
+	} else if placesKnown && isPlaceJurisdiction(jurisType) {
+		return nullString
+	}
+	query := newMatchName(juris)
 
-		ratio = fuzzy.Ratio(rowCounty, juris)
-		if isNewFuzzyMatch(ratio, matchThresh, max, state, row[9]) {
+	// pass over the counties of the jurisdiction's state, return id of greatest match above threshold.
+	// County id is the census data's state field concated with county, as a census county id is
+	// only unique witihn a state
+	max := matchThresh
+	match := nullString
+	for _, candidate := range index.candidates(state) {
+		if ratio := bestRatio(candidate.name, query); ratio > max {
 			max = ratio
-			match = row[7] + row[8]
+			match = candidate.id
 		}
-
 	}
 
 	return match
 }
-
-// helper method, returns condition checking whether or not we have a new fuzzy match
-func isNewFuzzyMatch(ratio int, thresh int, currentMax int, eq1 string, eq2 string) bool {
-	return ratio > thresh && ratio > currentMax && strings.ToLower(strings.TrimSpace(eq1)) == strings.ToLower(strings.TrimSpace(eq2))
-}
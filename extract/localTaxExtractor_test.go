@@ -0,0 +1,54 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+func TestLocalTaxCountyLinksCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("matches every jurisdiction of the local tax sheet")
+	}
+
+	workbook := sourcefileutils.Workbook{FilePath: testLocalTaxFile, Sheet: testLocalTaxSheet}
+	census := readTestCensus(t)
+	tests := []struct {
+		placeCountyFile string
+		// number of jurisdictions linked to a county by state id
+		linked map[string]int
+		// county of some of the jurisdictions by key
+		counties map[string]string
+	}{
+		// without place to county relationships, places are matched by name as counties are
+		{"", map[string]int{"01": 1, "06": 1, "08": 2, "18": 92, "19": 66, "20": 428, "21": 118, "24": 24, "26": 10,
+			"29": 2, "36": 1, "39": 217, "41": 1, "42": 2614, "54": 2},
+			map[string]string{"ohio:columbus": "39049", "ohio:akron": "NONE", "ohio:dayton": "NONE"}},
+		// with them, places are only linked to the counties they list
+		{"testdata/place_counties.csv", map[string]int{"01": 1, "18": 92, "19": 66, "20": 391, "21": 72, "24": 24,
+			"29": 1, "36": 1, "39": 49, "41": 1, "42": 2613},
+			map[string]string{"ohio:columbus": "39049", "ohio:akron": "39153", "ohio:dayton": "39113"}},
+	}
+
+	for _, tt := range tests {
+		data, _, err := GetLocalTaxData(workbook, census, 60, 0, "biweekly", "../data/jurisdiction_counties.yml", tt.placeCountyFile, nil, "NONE")
+		if err != nil {
+			t.Errorf("GetLocalTaxData with place file %q returned error %s", tt.placeCountyFile, err)
+			continue
+		}
+
+		linked := make(map[string]int)
+		for _, row := range data {
+			if row[4] != "NONE" {
+				linked[row[2]]++
+			}
+			if want, ok := tt.counties[row[1]]; ok && row[4] != want {
+				t.Errorf("county of %s with place file %q = %s, want %s", row[1], tt.placeCountyFile, row[4], want)
+			}
+		}
+		if !reflect.DeepEqual(linked, tt.linked) {
+			t.Errorf("linked jurisdictions by state with place file %q = %v, want %v", tt.placeCountyFile, linked, tt.linked)
+		}
+	}
+}
@@ -0,0 +1,396 @@
+/* Tokenizer and parser for the descriptions of local taxes published by the Tax Foundation */
+
+package extract
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode"
+
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
+)
+
+// kinds of tokens in a local tax description
+type tokenKind int
+
+const (
+	tokenEOF     tokenKind = iota
+	tokenPercent           // a number followed by %, i.e "1.25%"
+	tokenNumber            // a bare number, i.e "0.006918"
+	tokenMoney             // a dollar amount, i.e "$5.75"
+	tokenWord              // a lower cased word, i.e "month"
+	tokenSymbol            // a single symbol, one of + - / ( ) &
+)
+
+// a token of a local tax description. Numeric tokens hold the number without symbols or commas.
+type descToken struct {
+	kind tokenKind
+	text string
+	// byte offset of the token in the description
+	pos int
+}
+
+// typed error returned when a local tax description cannot be fully parsed
+type DescParseError struct {
+	Desc string
+	// byte offset of the token the parser stopped at
+	Pos    int
+	Reason string
+}
+
+func (e *DescParseError) Error() string {
+	return fmt.Sprintf("unable to parse local tax description %q at offset %v: %s", e.Desc, e.Pos, e.Reason)
+}
+
+// components of a local tax description. Fees are the amounts as they appear in the description, empty if not
+// part of the tax. Rates and the share of state liability are fractions.
+type LocalTaxRate struct {
+	// rate applying to all income, empty if the rate is a range or conditional
+	Rate           string
+	MonthFee       string
+	YearFee        string
+	PayPeriodFee   string
+	StateLiability string
+	// least and greatest rate of the tax, equal for a single rate
+	MinRate string
+	MaxRate string
+	// note on the conditions under which the rates or fees apply
+	Note string
+}
+
+// the flat fees of a tax over a year, given the number of pay periods in a year. Rates and state liability are not
+// included as they depend on income. Empty if the tax has no flat fees.
+func (r LocalTaxRate) AnnualFlatFee(payPeriods int) string {
+	total := new(big.Rat)
+	decimals := 0
+	hasFee := false
+	for _, fee := range []struct {
+		amount string
+		times  int
+	}{{r.MonthFee, 12}, {r.YearFee, 1}, {r.PayPeriodFee, payPeriods}} {
+		if fee.amount == "" {
+			continue
+		}
+		amount, _ := new(big.Rat).SetString(fee.amount)
+		total.Add(total, amount.Mul(amount, big.NewRat(int64(fee.times), 1)))
+		if d := decimalPlaces(fee.amount); d > decimals {
+			decimals = d
+		}
+		hasFee = true
+	}
+
+	if !hasFee {
+		return ""
+	}
+	return total.FloatString(decimals)
+}
+
+// notes on conditional rates and fees
+const (
+	noteDividendsOnly = "applies to interest and dividends only"
+	noteByProfession  = "varies by profession"
+	noteRateRange     = "varies within range"
+	noteFeeRange      = "yearly fee varies from $%s to $%s"
+)
+
+// number of weeks in a year, used to convert weekly fees to a yearly fee
+const weeksPerYear = 52
+
+// split a local tax description into tokens
+func tokenizeDesc(desc string) ([]descToken, error) {
+	var tokens []descToken
+	runes := []rune(desc)
+	// byte offset of each rune
+	offsets := make([]int, len(runes)+1)
+	o := 0
+	for i, r := range runes {
+		offsets[i] = o
+		o += len(string(r))
+	}
+	offsets[len(runes)] = o
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '$' || unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			kind := tokenNumber
+			if r == '$' {
+				kind = tokenMoney
+				i++
+			}
+			numStart := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == ',') {
+				i++
+			}
+			num := strings.ReplaceAll(string(runes[numStart:i]), ",", "")
+			if num == "" || strings.Count(num, ".") > 1 {
+				return nil, &DescParseError{Desc: desc, Pos: offsets[start], Reason: "malformed number"}
+			}
+			if kind == tokenNumber && i < len(runes) && runes[i] == '%' {
+				kind = tokenPercent
+				i++
+			}
+			tokens = append(tokens, descToken{kind: kind, text: num, pos: offsets[start]})
+		case unicode.IsLetter(r):
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '\'' || runes[i] == '.') {
+				i++
+			}
+			word := strings.TrimSuffix(strings.ToLower(string(runes[start:i])), ".")
+			tokens = append(tokens, descToken{kind: tokenWord, text: word, pos: offsets[start]})
+		case strings.ContainsRune("+-/()&", r):
+			i++
+			tokens = append(tokens, descToken{kind: tokenSymbol, text: string(r), pos: offsets[start]})
+		default:
+			return nil, &DescParseError{Desc: desc, Pos: offsets[start], Reason: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, descToken{kind: tokenEOF, pos: len(desc)}), nil
+}
+
+// recursive descent parser over the tokens of a description. The grammar is:
+//
+//	desc      := term ( "+" term )* EOF
+//	term      := PERCENT "of" "state" ( "liability" | "tax" )
+//	           | rate [ ( "-" | "to" ) rate ] [ condition ]
+//	           | MONEY period
+//	           | MONEY [ "municipal" ] "lst"
+//	           | MONEY "-" ( MONEY | NUMBER ) [ "fee" | ( "a" | "per" ) "year" ]
+//	rate      := PERCENT | NUMBER
+//	condition := "(" "no" "lst" ")" | "of" "interest" "&" "dividends" | "by" "profession"
+//	period    := "/" ( "month" | "mo" ) | ( "a" | "per" ) "year" | "per" "pay" "period" | "per" "week"
+type descParser struct {
+	desc   string
+	tokens []descToken
+	pos    int
+	result LocalTaxRate
+}
+
+// parse a local tax description into its components, returning a *DescParseError if it cannot be fully parsed.
+// An empty description is a tax with no components.
+func parseLocalTaxDesc(desc string) (LocalTaxRate, error) {
+	tokens, err := tokenizeDesc(desc)
+	if err != nil {
+		return LocalTaxRate{}, err
+	}
+
+	p := &descParser{desc: desc, tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return p.result, nil
+	}
+
+	for {
+		err = p.parseTerm()
+		if err != nil {
+			return LocalTaxRate{}, err
+		}
+		if !p.acceptSymbol("+") {
+			break
+		}
+	}
+
+	if p.peek().kind != tokenEOF {
+		return LocalTaxRate{}, p.errorf("unexpected %q", p.peek().text)
+	}
+
+	return p.result, nil
+}
+
+func (p *descParser) parseTerm() error {
+	t := p.next()
+	switch t.kind {
+	case tokenPercent, tokenNumber:
+		if t.kind == tokenPercent && p.acceptWords("of", "state") {
+			if !p.acceptWords("liability") && !p.acceptWords("tax") {
+				return p.errorf("expected state liability")
+			}
+			return p.set(&p.result.StateLiability, rateValue(t))
+		}
+		return p.parseRate(t)
+	case tokenMoney:
+		if p.acceptSymbol("-") {
+			return p.parseFeeRange(t.text)
+		}
+		return p.parseFee(t.text)
+	case tokenEOF:
+		return p.errorf("unexpected end of description")
+	default:
+		return &DescParseError{Desc: p.desc, Pos: t.pos, Reason: fmt.Sprintf("unexpected %q", t.text)}
+	}
+}
+
+// parse a rate or range of rates starting with the given token, along with any condition on the rates
+func (p *descParser) parseRate(t descToken) error {
+	low := rateValue(t)
+	high := low
+	ranged := false
+	if p.acceptSymbol("-") || p.acceptWords("to") {
+		next := p.next()
+		if next.kind != tokenPercent && next.kind != tokenNumber {
+			return &DescParseError{Desc: p.desc, Pos: next.pos, Reason: "expected a rate to end the range"}
+		}
+		high = rateValue(next)
+		ranged = true
+	}
+
+	note := ""
+	switch {
+	case p.acceptSymbol("("):
+		// a rate may note the absence of a local services tax
+		if !p.acceptWords("no", "lst") || !p.acceptSymbol(")") {
+			return p.errorf("unexpected note on rate")
+		}
+	case p.acceptWords("of", "interest"):
+		if !p.acceptSymbol("&") || !p.acceptWords("dividends") {
+			return p.errorf("expected interest and dividends")
+		}
+		note = noteDividendsOnly
+	case p.acceptWords("by", "profession"):
+		note = noteByProfession
+	case ranged:
+		note = noteRateRange
+	}
+
+	// the rates of a range are not always given in order
+	if compareDecimal(low, high) > 0 {
+		low, high = high, low
+	}
+
+	err := p.set(&p.result.MinRate, low)
+	if err != nil {
+		return err
+	}
+	err = p.set(&p.result.MaxRate, high)
+	if err != nil {
+		return err
+	}
+	if note != "" {
+		return p.set(&p.result.Note, note)
+	}
+
+	// an unconditional single rate applies to all income
+	return p.set(&p.result.Rate, low)
+}
+
+// parse a range of flat fees starting with the given amount. The fee depends on the taxpayer so only a note is kept.
+func (p *descParser) parseFeeRange(low string) error {
+	next := p.next()
+	if next.kind != tokenMoney && next.kind != tokenNumber {
+		return &DescParseError{Desc: p.desc, Pos: next.pos, Reason: "expected an amount to end the range"}
+	}
+
+	// fee ranges are yearly, with or without the period given
+	if !p.acceptWords("fee") && !p.acceptWords("a", "year") {
+		p.acceptWords("per", "year")
+	}
+
+	return p.set(&p.result.Note, fmt.Sprintf(noteFeeRange, low, next.text))
+}
+
+// parse the period of a fee with the given amount
+func (p *descParser) parseFee(amount string) error {
+	switch {
+	case p.acceptSymbol("/"):
+		if p.acceptWords("month") || p.acceptWords("mo") {
+			return p.set(&p.result.MonthFee, amount)
+		}
+		return p.errorf("unknown fee period")
+	case p.acceptWords("a", "year"), p.acceptWords("per", "year"):
+		return p.set(&p.result.YearFee, amount)
+	case p.acceptWords("per", "pay", "period"):
+		return p.set(&p.result.PayPeriodFee, amount)
+	case p.acceptWords("per", "week"):
+		return p.set(&p.result.YearFee, multiplyDecimal(amount, weeksPerYear))
+	case p.acceptWords("municipal", "lst"), p.acceptWords("lst"):
+		// the local services tax is a yearly fee
+		return p.set(&p.result.YearFee, amount)
+	default:
+		return p.errorf("fee without a period")
+	}
+}
+
+// set a component of the result, a component can only be given once
+func (p *descParser) set(field *string, value string) error {
+	if *field != "" {
+		return p.errorf("component given more than once")
+	}
+	*field = value
+	return nil
+}
+
+func (p *descParser) peek() descToken {
+	return p.tokens[p.pos]
+}
+
+func (p *descParser) next() descToken {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// consume the given symbol if it is next
+func (p *descParser) acceptSymbol(symbol string) bool {
+	if t := p.peek(); t.kind == tokenSymbol && t.text == symbol {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// consume the given sequence of words if they are next
+func (p *descParser) acceptWords(words ...string) bool {
+	for i, w := range words {
+		// the end token is last and is not a word, so the words cannot run past it
+		t := p.tokens[p.pos+i]
+		if t.kind != tokenWord || t.text != w {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *descParser) errorf(format string, a ...interface{}) error {
+	return &DescParseError{Desc: p.desc, Pos: p.tokens[p.pos].pos, Reason: fmt.Sprintf(format, a...)}
+}
+
+// helper method returning the fraction of a rate token. A bare number is a rate expressed as a fraction.
+func rateValue(t descToken) string {
+	text := t.text
+	if t.kind == tokenPercent {
+		text += "%"
+	}
+
+	// the tokenizer only makes rate tokens of well formed numbers
+	rate, _ := parseutils.ParseRate(text)
+	return rate.String()
+}
+
+// helper method comparing two decimal numbers, returning -1, 0 or 1
+func compareDecimal(a, b string) int {
+	ra, _ := new(big.Rat).SetString(a)
+	rb, _ := new(big.Rat).SetString(b)
+	return ra.Cmp(rb)
+}
+
+// helper method to multiply a decimal number by a whole number without float rounding
+func multiplyDecimal(num string, n int) string {
+	r, _ := new(big.Rat).SetString(num)
+	r.Mul(r, big.NewRat(int64(n), 1))
+
+	return r.FloatString(decimalPlaces(num))
+}
+
+// helper method returning the number of digits after the decimal point of a number
+func decimalPlaces(num string) int {
+	if i := strings.Index(num, "."); i >= 0 {
+		return len(num) - i - 1
+	}
+	return 0
+}
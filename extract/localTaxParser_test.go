@@ -0,0 +1,104 @@
+package extract
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseLocalTaxDesc(t *testing.T) {
+	tests := []struct {
+		desc string
+		want LocalTaxRate
+	}{
+		{"", LocalTaxRate{}},
+		// rates
+		{"1.25%", LocalTaxRate{Rate: "0.0125", MinRate: "0.0125", MaxRate: "0.0125"}},
+		{"0.006918", LocalTaxRate{Rate: "0.006918", MinRate: "0.006918", MaxRate: "0.006918"}},
+		{"1.0% (no LST)", LocalTaxRate{Rate: "0.01", MinRate: "0.01", MaxRate: "0.01"}},
+		// fees by period
+		{"$5.75/month", LocalTaxRate{MonthFee: "5.75"}},
+		{"$2/mo.", LocalTaxRate{MonthFee: "2"}},
+		{"$10 a year", LocalTaxRate{YearFee: "10"}},
+		{"$25 per year", LocalTaxRate{YearFee: "25"}},
+		{"$2.00 per pay period", LocalTaxRate{PayPeriodFee: "2.00"}},
+		{"$1.50 per week", LocalTaxRate{YearFee: "78.00"}},
+		{"$52 LST", LocalTaxRate{YearFee: "52"}},
+		{"$10 municipal LST", LocalTaxRate{YearFee: "10"}},
+		// share of state liability
+		{"1.75% of state liability", LocalTaxRate{StateLiability: "0.0175"}},
+		{"5.00% of state tax", LocalTaxRate{StateLiability: "0.05"}},
+		// ranges of rates, in either order
+		{"0.5% - 1%", LocalTaxRate{MinRate: "0.005", MaxRate: "0.01", Note: noteRateRange}},
+		{"2.25% to 1.5%", LocalTaxRate{MinRate: "0.015", MaxRate: "0.0225", Note: noteRateRange}},
+		// trailing notes
+		{"5% of interest & dividends", LocalTaxRate{MinRate: "0.05", MaxRate: "0.05", Note: noteDividendsOnly}},
+		{"0.1% - 0.3% by profession", LocalTaxRate{MinRate: "0.001", MaxRate: "0.003", Note: noteByProfession}},
+		{"$10-$100 fee", LocalTaxRate{Note: "yearly fee varies from $10 to $100"}},
+		{"$5 - 25 a year", LocalTaxRate{Note: "yearly fee varies from $5 to $25"}},
+		// terms joined by +
+		{"1% + $52 LST", LocalTaxRate{Rate: "0.01", MinRate: "0.01", MaxRate: "0.01", YearFee: "52"}},
+		{"$4/month + $1,000 per year", LocalTaxRate{MonthFee: "4", YearFee: "1000"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseLocalTaxDesc(tt.desc)
+		if err != nil {
+			t.Errorf("parseLocalTaxDesc(%q) returned error %s", tt.desc, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseLocalTaxDesc(%q) = %+v, want %+v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestParseLocalTaxDescErrors(t *testing.T) {
+	tests := []struct {
+		desc string
+		// byte offset the error is reported at
+		pos int
+	}{
+		{"1.2.3%", 0},
+		{"1% #", 3},
+		{"$5", 2},
+		{"$5/day", 3},
+		{"1% of state", 11},
+		{"1% - $5", 5},
+		{"$5 - month", 5},
+		{"1% (a)", 4},
+		{"1% of interest", 14},
+		{"1% + 2%", 7},
+		{"1% +", 4},
+		{"varies", 0},
+		{"1% 2%", 3},
+	}
+
+	for _, tt := range tests {
+		_, err := parseLocalTaxDesc(tt.desc)
+		var descErr *DescParseError
+		if !errors.As(err, &descErr) {
+			t.Errorf("parseLocalTaxDesc(%q) returned %v, want a *DescParseError", tt.desc, err)
+			continue
+		}
+		if descErr.Desc != tt.desc || descErr.Pos != tt.pos {
+			t.Errorf("parseLocalTaxDesc(%q) failed at offset %v of %q, want offset %v", tt.desc, descErr.Pos, descErr.Desc, tt.pos)
+		}
+	}
+}
+
+func TestAnnualFlatFee(t *testing.T) {
+	tests := []struct {
+		rate LocalTaxRate
+		want string
+	}{
+		{LocalTaxRate{Rate: "0.01"}, ""},
+		{LocalTaxRate{MonthFee: "5.75"}, "69.00"},
+		{LocalTaxRate{YearFee: "52", PayPeriodFee: "2.00"}, "104.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.rate.AnnualFlatFee(26); got != tt.want {
+			t.Errorf("%+v.AnnualFlatFee(26) = %q, want %q", tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+/* Logic to fetch the Tax Foundation workbooks into a local content addressed cache */
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+// an entry of the sources config, a workbook to fetch
+type SourceConfig struct {
+	// url of the workbook, resolved against the base url unless absolute
+	Url  string `yaml:"url"`
+	Type string `yaml:"type"`
+	Year int    `yaml:"year"`
+}
+
+// public method to read the sources config listing the workbooks to fetch
+func ReadSourcesConfig(filePath string) ([]SourceConfig, error) {
+	yfile, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading in the sources config %s: %s", filePath, err)
+	}
+
+	var config struct {
+		Sources []SourceConfig `yaml:"sources"`
+	}
+	err = yaml.Unmarshal(yfile, &config)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error parsing the sources config %s: %s", filePath, err)
+	}
+
+	for i, src := range config.Sources {
+		if src.Url == "" || src.Year == 0 {
+			return nil, fmt.Errorf("Source %v of the sources config %s is missing its url or year", i, filePath)
+		}
+		if src.Type != sourcefileutils.FEDERAL_WORKBOOK && src.Type != sourcefileutils.STATE_WORKBOOK && src.Type != sourcefileutils.LOCAL_WORKBOOK {
+			return nil, fmt.Errorf("Source %v of the sources config %s has the unknown type %s", i, filePath, src.Type)
+		}
+	}
+
+	return config.Sources, nil
+}
+
+// public method to fetch the given sources into the cache directory, returning the workbook of each. A file is cached
+// under its checksum, so a file fetched again is stored once. Each file is read as the workbook of its type and year,
+// and an error is returned if any source cannot be fetched or read.
+func FetchSources(sources []SourceConfig, baseUrl string, cacheDir string, attempts int) ([]sourcefileutils.Workbook, error) {
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		return nil, fmt.Errorf("The base url %s is invalid: %s", baseUrl, err)
+	}
+
+	err = os.MkdirAll(cacheDir, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error creating the source cache %s: %s", cacheDir, err)
+	}
+
+	var workbooks []sourcefileutils.Workbook
+	for _, src := range sources {
+		ref, err := url.Parse(src.Url)
+		if err != nil {
+			return nil, fmt.Errorf("The url %s of the %s source of %v is invalid: %s", src.Url, src.Type, src.Year, err)
+		}
+		sourceUrl := base.ResolveReference(ref).String()
+
+		// the reader of the file is chosen by the extension of the url
+		ext := strings.ToLower(path.Ext(ref.Path))
+		if !sourcefileutils.IsSourceFile(ext) {
+			return nil, fmt.Errorf("The url %s of the %s source of %v is not of a source file format", sourceUrl, src.Type, src.Year)
+		}
+
+		filePath, cached, err := downloadSource(sourceUrl, cacheDir, ext, attempts)
+		if err != nil {
+			return nil, err
+		}
+
+		wb, err := sourcefileutils.DescribeSource(filePath, src.Type, src.Year)
+		if err != nil {
+			// keep an unreadable file out of the cache
+			if !cached {
+				os.Remove(filePath)
+			}
+			return nil, fmt.Errorf("The %s source of %v fetched from %s is not valid: %s", src.Type, src.Year, sourceUrl, err)
+		}
+		wb.Url = sourceUrl
+		workbooks = append(workbooks, wb)
+
+		logger.Info("Fetched the %s source of %v from %s to %s", src.Type, src.Year, sourceUrl, filePath)
+	}
+
+	return workbooks, nil
+}
+
+// helper method to download a url into the cache directory, retrying up to the given number of attempts. Returns the
+// path of the file, named by its checksum, and whether the file was already in the cache.
+func downloadSource(sourceUrl string, cacheDir string, ext string, attempts int) (string, bool, error) {
+	var tmpPath string
+	var checksum string
+	var err error
+	for i := 1; i <= attempts; i++ {
+		tmpPath, checksum, err = downloadToTemp(sourceUrl, cacheDir, ext)
+		if err == nil {
+			break
+		} else if i == attempts {
+			return "", false, fmt.Errorf("Exceeded %v attempts trying to fetch %s. Error: %s", attempts, sourceUrl, err)
+		}
+
+		sleepTime := 10 * i
+		logger.Warn("Fetching %s failed with error: %s. Sleeping for %v and trying again", sourceUrl, err, sleepTime)
+		time.Sleep(time.Duration(sleepTime) * time.Second)
+	}
+
+	filePath := filepath.Join(cacheDir, checksum+ext)
+	if _, err := os.Stat(filePath); err == nil {
+		os.Remove(tmpPath)
+		return filePath, true, nil
+	}
+
+	err = os.Rename(tmpPath, filePath)
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", false, fmt.Errorf("There was an error adding %s to the source cache: %s", sourceUrl, err)
+	}
+
+	return filePath, false, nil
+}
+
+// helper method to download a url to a temporary file of the cache directory, returning its path and the hex encoded
+// SHA-256 checksum of its content
+func downloadToTemp(sourceUrl string, cacheDir string, ext string) (string, string, error) {
+	resp, err := http.Get(sourceUrl)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("recieved status code %v", resp.StatusCode)
+	}
+
+	tmp, err := ioutil.TempFile(cacheDir, ".download-*"+ext)
+	if err != nil {
+		return "", "", err
+	}
+
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", "", err
+	}
+
+	return tmp.Name(), hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,135 @@
+package extract
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+// helper method serving the local tax workbook as a fixture at /local.xlsx, a file that is not a workbook at
+// /broken.xlsx and 404 at any other path
+func newTestSourceServer(t *testing.T) *httptest.Server {
+	workbook, err := ioutil.ReadFile(testLocalTaxFile)
+	if err != nil {
+		t.Fatalf("reading fixture workbook: %s", err)
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/local.xlsx":
+			w.Write(workbook)
+		case "/broken.xlsx":
+			w.Write([]byte("not a workbook"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+// helper method returning the names of the files of the cache directory
+func cacheFiles(t *testing.T, cacheDir string) []string {
+	files, err := ioutil.ReadDir(cacheDir)
+	if err != nil {
+		t.Fatalf("reading cache %s: %s", cacheDir, err)
+	}
+
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+
+	return names
+}
+
+func TestFetchSources(t *testing.T) {
+	server := newTestSourceServer(t)
+	defer server.Close()
+	cacheDir := t.TempDir()
+	sources := []SourceConfig{{Url: "local.xlsx", Type: sourcefileutils.LOCAL_WORKBOOK, Year: 2019}}
+
+	checksum, err := sourcefileutils.FileChecksum(testLocalTaxFile)
+	if err != nil {
+		t.Fatalf("FileChecksum(%s) returned error %s", testLocalTaxFile, err)
+	}
+	want := sourcefileutils.Workbook{
+		Type:      sourcefileutils.LOCAL_WORKBOOK,
+		Year:      2019,
+		FilePath:  filepath.Join(cacheDir, checksum+".xlsx"),
+		Sheet:     testLocalTaxSheet,
+		Published: 2019,
+		Checksum:  checksum,
+		Url:       server.URL + "/local.xlsx",
+	}
+
+	// a file fetched again is stored once, under its checksum
+	for i := 0; i < 2; i++ {
+		workbooks, err := FetchSources(sources, server.URL+"/", cacheDir, 1)
+		if err != nil {
+			t.Fatalf("FetchSources returned error %s", err)
+		}
+		if !reflect.DeepEqual(workbooks, []sourcefileutils.Workbook{want}) {
+			t.Errorf("FetchSources() = %+v, want %+v", workbooks, want)
+		}
+		if files := cacheFiles(t, cacheDir); !reflect.DeepEqual(files, []string{checksum + ".xlsx"}) {
+			t.Errorf("files of the cache after fetch %v = %q, want only %s.xlsx", i+1, files, checksum)
+		}
+	}
+
+	// the pointer file points to the fetched workbook
+	pointerFile := filepath.Join(cacheDir, "active.yml")
+	err = sourcefileutils.WriteActiveSources(pointerFile, []sourcefileutils.Workbook{want}, "2022-06-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("WriteActiveSources returned error %s", err)
+	}
+	active, err := sourcefileutils.ReadActiveSources(pointerFile)
+	if err != nil {
+		t.Fatalf("ReadActiveSources returned error %s", err)
+	}
+	if !reflect.DeepEqual(active, []sourcefileutils.Workbook{want}) {
+		t.Errorf("ReadActiveSources() = %+v, want %+v", active, want)
+	}
+
+	// a cached workbook changed since it was fetched no longer matches the pointer
+	err = ioutil.WriteFile(want.FilePath, []byte("changed"), 0644)
+	if err != nil {
+		t.Fatalf("changing the cached workbook: %s", err)
+	}
+	if _, err := sourcefileutils.ReadActiveSources(pointerFile); err == nil {
+		t.Errorf("ReadActiveSources() of a changed workbook returned no error")
+	}
+}
+
+func TestFetchSourcesErrors(t *testing.T) {
+	server := newTestSourceServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		url string
+		// part of the error expected
+		err string
+	}{
+		{"missing.xlsx", "status code 404"},
+		{server.URL + "/missing.xlsx", "status code 404"},
+		{"broken.xlsx", "is not valid"},
+		{"local.txt", "is not of a source file format"},
+	}
+
+	for _, tt := range tests {
+		cacheDir := t.TempDir()
+		sources := []SourceConfig{{Url: tt.url, Type: sourcefileutils.LOCAL_WORKBOOK, Year: 2019}}
+		_, err := FetchSources(sources, server.URL+"/", cacheDir, 1)
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("FetchSources(%s) returned error %v, want an error containing %q", tt.url, err, tt.err)
+		}
+		// nothing is left in the cache of a source that cannot be fetched or read
+		if files := cacheFiles(t, cacheDir); len(files) > 0 {
+			t.Errorf("FetchSources(%s) left %q in the cache", tt.url, files)
+		}
+	}
+}
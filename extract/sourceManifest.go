@@ -0,0 +1,60 @@
+/* Logic to describe the sources of a run for the source manifest */
+
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+// type of the census source in the manifest, alongside the workbook types
+const CENSUS_SOURCE = "census"
+
+// licenses of the sources
+const (
+	TAX_FOUNDATION_LICENSE = "Tax foundation works are licensed under a Creative Commons Attribution NonCommercial 4.0 " +
+		"International License. https://taxfoundation.org/copyright-notice/"
+	CENSUS_LICENSE = "This product uses the Census Bureau Data API but is not endorsed or certified by the Census Bureau."
+)
+
+// publication pages of the Tax Foundation workbooks
+var workbookUrls = map[string]string{
+	sourcefileutils.FEDERAL_WORKBOOK: "https://taxfoundation.org/publications/federal-tax-rates-and-tax-brackets/",
+	sourcefileutils.STATE_WORKBOOK:   "https://taxfoundation.org/publications/state-individual-income-tax-rates-and-brackets/",
+	sourcefileutils.LOCAL_WORKBOOK:   "https://taxfoundation.org/local-income-taxes-2019/",
+}
+
+// public method returning the manifest entry of a workbook loaded by the given run. The entry holds the run id,
+// source type, source url, source path, published year, tax year, checksum and license, in that order. The url of a
+// fetched workbook is the one it was fetched from, else the publication page of its type.
+func GetWorkbookManifest(runId string, workbook sourcefileutils.Workbook) []string {
+	sourceUrl := workbook.Url
+	if sourceUrl == "" {
+		sourceUrl = workbookUrls[workbook.Type]
+	}
+
+	return []string{runId, workbook.Type, sourceUrl, workbook.FilePath, fmt.Sprint(workbook.Published),
+		fmt.Sprint(workbook.Year), workbook.Checksum, TAX_FOUNDATION_LICENSE}
+}
+
+// public method returning the manifest entry of the census data loaded by the given run, with the checksum of the
+// census response
+func GetCensusManifest(runId string, checksum string) []string {
+	return []string{runId, CENSUS_SOURCE, CENSUS_URL, GetCensusQuery(), CENSUS_YEAR, CENSUS_YEAR, checksum, CENSUS_LICENSE}
+}
+
+// public method combining the checksums of the sources of a stage and the settings it runs with into one checksum,
+// which changes if any of them change
+func GetStageChecksum(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// public method returning the parts of a stage checksum identifying a workbook sheet
+func GetWorkbookChecksumParts(workbook sourcefileutils.Workbook) []string {
+	return []string{workbook.Checksum, workbook.Sheet, fmt.Sprint(workbook.Year)}
+}
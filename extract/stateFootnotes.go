@@ -0,0 +1,106 @@
+/* Logic to read the footnotes of the state sheet and the special cases its cells are given as text */
+
+package extract
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
+)
+
+// a footnote of the block below the state table, i.e "(a) Local income taxes are excluded."
+var footnoteRow = regexp.MustCompile(`(?s)^\(([a-zA-Z]{1,3})\)\s+(\S.*)$`)
+
+// a rate naming the only income a state taxes, i.e "5% on interest and dividends only"
+var rateCondition = regexp.MustCompile(`(?i)\bon\s+(.+?)\s+only$`)
+
+// a cell of only footnote letters, as when the markers of a state are split over rows, i.e "(a, e," then "r, s)", or
+// are missing a comma, i.e "(a, e, r, s ff, hh)"
+var markerFragment = regexp.MustCompile(`^[(\[]?\s*[a-z]{1,3}(?:(?:\s*,\s*|\s+)[a-z]{1,3})*\s*,?\s*[)\]]?$`)
+
+// a letter of a marker fragment
+var fragmentLetter = regexp.MustCompile(`[a-z]{1,3}`)
+
+// the rate of a state without an income tax
+const NO_INCOME_TAX_RATE string = "none"
+
+// helper method to parse a footnote of the state sheet, returning its marker and text
+func parseFootnoteRow(cell string) (string, string, bool) {
+	m := footnoteRow.FindStringSubmatch(strings.TrimSpace(cell))
+	if m == nil {
+		return "", "", false
+	}
+
+	return strings.ToLower(m[1]), strings.TrimSpace(m[2]), true
+}
+
+// helper method to split a cell of the state sheet into its text and the letters of its footnote markers, including
+// markers split over rows
+func splitCellMarkers(cell string) (string, []string) {
+	text, markers := parseutils.SplitFootnoteMarkers(cell)
+	if text != "" && markerFragment.MatchString(text) {
+		return "", append(markers, fragmentLetter.FindAllString(text, -1)...)
+	}
+
+	return text, markers
+}
+
+// helper method to return the flags of the income a state taxes from the rate of its first bracket, in the order
+// of no income tax, interest and dividends only and capital gains only
+func getStateIncomeFlags(rate string) []string {
+	text, _ := parseutils.SplitFootnoteMarkers(rate)
+	text = strings.ToLower(text)
+
+	interestOnly, capitalGainsOnly := false, false
+	if m := rateCondition.FindStringSubmatch(text); m != nil {
+		interestOnly = strings.Contains(m[1], "interest") && strings.Contains(m[1], "dividend")
+		capitalGainsOnly = strings.Contains(m[1], "capital gains")
+	}
+
+	return []string{strconv.FormatBool(text == NO_INCOME_TAX_RATE), strconv.FormatBool(interestOnly), strconv.FormatBool(capitalGainsOnly)}
+}
+
+// helper method returning if a deduction or exemption is given as a tax credit, i.e "$58 credit"
+func isCredit(cell string) string {
+	return strconv.FormatBool(strings.Contains(strings.ToLower(cell), "credit"))
+}
+
+// helper method to link the footnote markers of each column of a row of the sheet to the given state, skipping links
+// already made
+func appendFootnoteCells(cells [][]string, seen map[string]bool, stateId string, record sourcefileutils.Record) [][]string {
+	for _, column := range stateColumns {
+		_, markers := splitCellMarkers(record.Get(column.Name))
+		for _, marker := range markers {
+			key := strings.Join([]string{stateId, column.Name, marker}, "|")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			cells = append(cells, []string{stateId, column.Name, marker})
+		}
+	}
+
+	return cells
+}
+
+// helper method to drop links to markers the footnote block does not define, as they have no text to link to
+func dropUndefinedFootnotes(cells [][]string, footnotes [][]string) [][]string {
+	defined := make(map[string]bool)
+	for _, footnote := range footnotes {
+		defined[footnote[0]] = true
+	}
+
+	kept := [][]string{}
+	for _, cell := range cells {
+		if !defined[cell[2]] {
+			logger.Warn("The footnote marker (%s) of the %s column of state %s is not defined by the sheet, so it is not linked", cell[2], cell[1], cell[0])
+			continue
+		}
+		kept = append(kept, cell)
+	}
+
+	return kept
+}
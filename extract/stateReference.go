@@ -0,0 +1,100 @@
+/* Logic defining the bundled reference of states the state and local tax data is identified by */
+
+package extract
+
+import (
+	"strings"
+)
+
+// a state of the reference, identified by its FIPS code
+type stateReference struct {
+	fips         string
+	abbreviation string
+	name         string
+	// other names the sources give the state, such as the abbreviations of older Tax Foundation sheets
+	aliases []string
+}
+
+// states, the District of Columbia and Puerto Rico in order of FIPS code. The FIPS code and name are as the census
+// gives them, and the abbreviation is the USPS code.
+var stateReferences = []stateReference{
+	{"01", "AL", "Alabama", []string{"Ala."}},
+	{"02", "AK", "Alaska", nil},
+	{"04", "AZ", "Arizona", []string{"Ariz."}},
+	{"05", "AR", "Arkansas", []string{"Ark."}},
+	{"06", "CA", "California", []string{"Calif.", "Cal."}},
+	{"08", "CO", "Colorado", []string{"Colo."}},
+	{"09", "CT", "Connecticut", []string{"Conn."}},
+	{"10", "DE", "Delaware", []string{"Del."}},
+	{"11", "DC", "District of Columbia", []string{"D.C.", "Washington, D.C.", "Dist. of Col."}},
+	{"12", "FL", "Florida", []string{"Fla."}},
+	{"13", "GA", "Georgia", []string{"Ga."}},
+	{"15", "HI", "Hawaii", nil},
+	{"16", "ID", "Idaho", nil},
+	{"17", "IL", "Illinois", []string{"Ill."}},
+	{"18", "IN", "Indiana", []string{"Ind."}},
+	{"19", "IA", "Iowa", nil},
+	{"20", "KS", "Kansas", []string{"Kans.", "Kan."}},
+	{"21", "KY", "Kentucky", []string{"Ky."}},
+	{"22", "LA", "Louisiana", []string{"La."}},
+	{"23", "ME", "Maine", nil},
+	{"24", "MD", "Maryland", []string{"Md."}},
+	{"25", "MA", "Massachusetts", []string{"Mass."}},
+	{"26", "MI", "Michigan", []string{"Mich."}},
+	{"27", "MN", "Minnesota", []string{"Minn."}},
+	{"28", "MS", "Mississippi", []string{"Miss."}},
+	{"29", "MO", "Missouri", []string{"Mo."}},
+	{"30", "MT", "Montana", []string{"Mont."}},
+	{"31", "NE", "Nebraska", []string{"Nebr.", "Neb."}},
+	{"32", "NV", "Nevada", []string{"Nev."}},
+	{"33", "NH", "New Hampshire", []string{"N.H."}},
+	{"34", "NJ", "New Jersey", []string{"N.J."}},
+	{"35", "NM", "New Mexico", []string{"N.M.", "N. Mex."}},
+	{"36", "NY", "New York", []string{"N.Y."}},
+	{"37", "NC", "North Carolina", []string{"N.C."}},
+	{"38", "ND", "North Dakota", []string{"N.D.", "N. Dak."}},
+	{"39", "OH", "Ohio", nil},
+	{"40", "OK", "Oklahoma", []string{"Okla."}},
+	{"41", "OR", "Oregon", []string{"Ore.", "Oreg."}},
+	{"42", "PA", "Pennsylvania", []string{"Pa.", "Penn."}},
+	{"44", "RI", "Rhode Island", []string{"R.I."}},
+	{"45", "SC", "South Carolina", []string{"S.C."}},
+	{"46", "SD", "South Dakota", []string{"S.D.", "S. Dak."}},
+	{"47", "TN", "Tennessee", []string{"Tenn."}},
+	{"48", "TX", "Texas", []string{"Tex."}},
+	{"49", "UT", "Utah", nil},
+	{"50", "VT", "Vermont", []string{"Vt."}},
+	{"51", "VA", "Virginia", []string{"Va."}},
+	{"53", "WA", "Washington", []string{"Wash."}},
+	{"54", "WV", "West Virginia", []string{"W.Va.", "W. Va."}},
+	{"55", "WI", "Wisconsin", []string{"Wis.", "Wisc."}},
+	{"56", "WY", "Wyoming", []string{"Wyo."}},
+	{"72", "PR", "Puerto Rico", []string{"P.R."}},
+}
+
+// the states of the reference by each of their names, abbreviations and aliases, normalized
+var stateLookup = newStateLookup()
+
+// helper method to index the states of the reference by their names, abbreviations and aliases
+func newStateLookup() map[string]stateReference {
+	mp := make(map[string]stateReference)
+	for _, state := range stateReferences {
+		for _, name := range append([]string{state.name, state.abbreviation}, state.aliases...) {
+			mp[normalizeStateName(name)] = state
+		}
+	}
+
+	return mp
+}
+
+// helper method to normalize a state name for lookup. Case, whitespace and periods are ignored, so "W. Va." and
+// "W.Va." are the same name and an abbreviation such as "N.C." is the USPS code.
+func normalizeStateName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(strings.ReplaceAll(name, ".", " ")), ""))
+}
+
+// helper method to find a state of the reference by its name, USPS code or an alias
+func lookupState(name string) (stateReference, bool) {
+	state, ok := stateLookup[normalizeStateName(name)]
+	return state, ok
+}
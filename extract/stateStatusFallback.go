@@ -0,0 +1,78 @@
+/* Logic to derive the state schedules of filing statuses the state sheet does not publish */
+
+package extract
+
+import (
+	"math/big"
+
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
+)
+
+// rules deriving the schedule of a filing status from a published one, as recorded on the derived rows
+const (
+	// the schedule of a single filer, as most states tax a head of household without a schedule of their own
+	FALLBACK_SINGLE string = "single"
+	// the amounts of the married filing jointly schedule halved, as most states split the joint schedule between
+	// spouses filing separately
+	FALLBACK_HALF_JOINT string = "half married filing jointly"
+)
+
+// filing statuses derived for a state that does not publish them, with the published status they are derived from,
+// the rule recorded on the row and the divisor of the amounts of the published status
+var stateStatusFallbacks = []struct {
+	status  string
+	from    string
+	rule    string
+	divisor int64
+}{
+	{HEAD_OF_HOUSEHOLD_STATUS, SINGLE_STATUS, FALLBACK_SINGLE, 1},
+	{MARRIED_SEPARATE_STATUS, MARRIED_JOINT_STATUS, FALLBACK_HALF_JOINT, 2},
+}
+
+// helper method to derive the rows of each fallback status a state has no rows for, from the rows of the status it
+// falls back to. Rows begin with the state id and filing status id and end with the fallback rule, and the amounts
+// are the positions of the dollar amounts to divide.
+func addStatusFallbacks(rows [][]string, amounts []int, nullString string) [][]string {
+	// the filing statuses published by each state
+	published := make(map[string]bool)
+	for _, row := range rows {
+		published[row[0]+"|"+row[1]] = true
+	}
+
+	for _, fallback := range stateStatusFallbacks {
+		for _, row := range rows {
+			if row[1] != fallback.from || published[row[0]+"|"+fallback.status] {
+				continue
+			}
+
+			derived := append([]string{}, row...)
+			derived[1] = fallback.status
+			for _, i := range amounts {
+				derived[i] = divideAmount(derived[i], fallback.divisor, nullString)
+			}
+			derived[len(derived)-1] = fallback.rule
+			rows = append(rows, derived)
+		}
+	}
+
+	return rows
+}
+
+// helper method to divide a dollar amount, rounding to the nearest whole dollar, halves up, as the bracket and
+// deduction columns are whole dollars. The null string is kept.
+func divideAmount(amount string, divisor int64, nullString string) string {
+	if divisor == 1 || amount == nullString {
+		return amount
+	}
+
+	value, err := parseutils.ParseAmount(amount)
+	if err != nil {
+		return nullString
+	}
+
+	// the floor of amount / divisor + 1/2
+	r := value.Rat()
+	denom := new(big.Int).Mul(r.Denom(), big.NewInt(2*divisor))
+	num := new(big.Int).Add(new(big.Int).Mul(r.Num(), big.NewInt(2)), new(big.Int).Mul(r.Denom(), big.NewInt(divisor)))
+	return new(big.Int).Div(num, denom).String()
+}
@@ -0,0 +1,109 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDivideAmount(t *testing.T) {
+	tests := []struct {
+		amount  string
+		divisor int64
+		want    string
+	}{
+		{"10275", 2, "5138"},
+		{"2000", 2, "1000"},
+		{"2001", 2, "1001"},
+		{"$1,500.50", 2, "750"},
+		{"$1,501.00", 2, "751"},
+		{"1.5", 2, "1"},
+		{"-3", 2, "-1"},
+		{"$10,275", 1, "$10,275"},
+		{"NONE", 2, "NONE"},
+		{"n.a.", 2, "NONE"},
+	}
+
+	for _, tt := range tests {
+		if got := divideAmount(tt.amount, tt.divisor, "NONE"); got != tt.want {
+			t.Errorf("divideAmount(%q, %v) = %q, want %q", tt.amount, tt.divisor, got, tt.want)
+		}
+	}
+}
+
+func TestAddStatusFallbacks(t *testing.T) {
+	tests := []struct {
+		name string
+		// state, filing status, ordinal, rate, lower bound, upper bound and fallback rule of each bracket
+		rows [][]string
+		want [][]string
+	}{
+		{
+			name: "single and married filing jointly published",
+			rows: [][]string{
+				{"1", "1", "1", "0.02", "0", "5137", ""},
+				{"1", "1", "2", "0.04", "5137", "NONE", ""},
+				{"1", "2", "1", "0.02", "0", "10275", ""},
+				{"1", "2", "2", "0.04", "10275", "NONE", ""},
+			},
+			want: [][]string{
+				{"1", "1", "1", "0.02", "0", "5137", ""},
+				{"1", "1", "2", "0.04", "5137", "NONE", ""},
+				{"1", "2", "1", "0.02", "0", "10275", ""},
+				{"1", "2", "2", "0.04", "10275", "NONE", ""},
+				{"1", "3", "1", "0.02", "0", "5137", "single"},
+				{"1", "3", "2", "0.04", "5137", "NONE", "single"},
+				{"1", "4", "1", "0.02", "0", "5138", "half married filing jointly"},
+				{"1", "4", "2", "0.04", "5138", "NONE", "half married filing jointly"},
+			},
+		},
+		{
+			name: "every status published",
+			rows: [][]string{
+				{"2", "1", "1", "0.05", "0", "NONE", ""},
+				{"2", "2", "1", "0.05", "0", "NONE", ""},
+				{"2", "3", "1", "0.04", "0", "NONE", ""},
+				{"2", "4", "1", "0.03", "0", "NONE", ""},
+			},
+			want: [][]string{
+				{"2", "1", "1", "0.05", "0", "NONE", ""},
+				{"2", "2", "1", "0.05", "0", "NONE", ""},
+				{"2", "3", "1", "0.04", "0", "NONE", ""},
+				{"2", "4", "1", "0.03", "0", "NONE", ""},
+			},
+		},
+		{
+			name: "only single published",
+			rows: [][]string{
+				{"3", "1", "1", "NONE", "NONE", "NONE", ""},
+			},
+			want: [][]string{
+				{"3", "1", "1", "NONE", "NONE", "NONE", ""},
+				{"3", "3", "1", "NONE", "NONE", "NONE", "single"},
+			},
+		},
+		{
+			name: "statuses published by another state",
+			rows: [][]string{
+				{"4", "1", "1", "0.03", "0", "NONE", ""},
+				{"4", "2", "1", "0.03", "1001", "NONE", ""},
+				{"5", "3", "1", "0.04", "0", "NONE", ""},
+				{"5", "4", "1", "0.04", "0", "NONE", ""},
+			},
+			want: [][]string{
+				{"4", "1", "1", "0.03", "0", "NONE", ""},
+				{"4", "2", "1", "0.03", "1001", "NONE", ""},
+				{"5", "3", "1", "0.04", "0", "NONE", ""},
+				{"5", "4", "1", "0.04", "0", "NONE", ""},
+				{"4", "3", "1", "0.03", "0", "NONE", "single"},
+				{"4", "4", "1", "0.03", "501", "NONE", "half married filing jointly"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		got := addStatusFallbacks(tt.rows, []int{2 + bracketLower, 2 + bracketUpper}, "NONE")
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("addStatusFallbacks(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
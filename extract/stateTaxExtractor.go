@@ -3,98 +3,166 @@
 package extract
 
 import (
-	"regexp"
-	"strconv"
 	"strings"
 
+	parseutils "github.com/Matthew-Curry/re-region-etl/parseUtils"
 	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
 )
 
-const STATE_TAX_FILE = "data/State-Individual-Income-Tax-Rates-and-Brackets-for-2022-v.xlsx"
-
-// helper method to build data structures for state tax brackets and exemptions
-func GetStateTaxData(censusData [][]string, nullString string) ([][]string, [][]string, error) {
+// columns of the state sheet, which has a two row header grouping the columns by filer
+var stateColumns = []sourcefileutils.Column{
+	{Name: "state", Labels: []string{"State"}, Required: true},
+	{Name: "single_rate", Labels: []string{"Rates"}, Group: "Single Filer", Required: true},
+	{Name: "single_bracket", Labels: []string{"Brackets"}, Group: "Single Filer", Required: true},
+	{Name: "married_rate", Labels: []string{"Rates"}, Group: "Married Filing Jointly", Required: true},
+	{Name: "married_bracket", Labels: []string{"Brackets"}, Group: "Married Filing Jointly", Required: true},
+	{Name: "deduction_single", Labels: []string{"Single"}, Group: "Standard Deduction", Required: true},
+	{Name: "deduction_couple", Labels: []string{"Couple"}, Group: "Standard Deduction", Required: true},
+	{Name: "exemption_single", Labels: []string{"Single"}, Group: "Personal Exemption", Required: true},
+	{Name: "exemption_couple", Labels: []string{"Couple"}, Group: "Personal Exemption", Required: true},
+	{Name: "exemption_dependent", Labels: []string{"Dependent"}, Group: "Personal Exemption", Required: true},
+	// head of household and married filing separately schedules are published by few editions, if any
+	{Name: "head_rate", Labels: []string{"Rates"}, Group: "Head of Household"},
+	{Name: "head_bracket", Labels: []string{"Brackets"}, Group: "Head of Household"},
+	{Name: "separate_rate", Labels: []string{"Rates"}, Group: "Married Filing Separately"},
+	{Name: "separate_bracket", Labels: []string{"Brackets"}, Group: "Married Filing Separately"},
+	{Name: "deduction_head", Labels: []string{"Head of Household"}, Group: "Standard Deduction"},
+	{Name: "deduction_separate", Labels: []string{"Married Filing Separately", "Separate"}, Group: "Standard Deduction"},
+	{Name: "exemption_head", Labels: []string{"Head of Household"}, Group: "Personal Exemption"},
+	{Name: "exemption_separate", Labels: []string{"Married Filing Separately", "Separate"}, Group: "Personal Exemption"},
+}
 
-	// build hashmap of lower state to id
-	mp := make(map[string]string)
-	for _, row := range censusData {
-		state := strings.ToLower(row[9])
-		// add mapping for state if it does not already exist
-		if _, ok := mp[state]; !ok {
-			mp[state] = row[7]
-		}
+// filing status of each group of columns of the state sheet, in the order they are loaded
+var stateFilerStatuses = []struct {
+	rate      string
+	bracket   string
+	deduction string
+	exemption string
+	status    string
+	// whether the columns of the filing status may be missing or empty, in which case its schedule is derived
+	optional bool
+}{
+	{"single_rate", "single_bracket", "deduction_single", "exemption_single", SINGLE_STATUS, false},
+	{"married_rate", "married_bracket", "deduction_couple", "exemption_couple", MARRIED_JOINT_STATUS, false},
+	{"head_rate", "head_bracket", "deduction_head", "exemption_head", HEAD_OF_HOUSEHOLD_STATUS, true},
+	{"separate_rate", "separate_bracket", "deduction_separate", "exemption_separate", MARRIED_SEPARATE_STATUS, true},
+}
 
-	}
+// helper method to build data structures for state tax brackets, states, deductions and footnotes from the year's
+// sheet of the given state workbook. States are identified by the bundled state reference, so no census data is
+// needed. Brackets are rows of the state id, filing status id, ordinal, rate, lower bound and upper bound, the lower
+// bound of the next bracket of the filing status or null for the top bracket, and the fallback rule the bracket is
+// derived by, null if the sheet publishes the schedule of the filing status. States are rows of the state id, name,
+// USPS abbreviation, dependent exemption, whether the dependent exemption is a credit, and whether the state
+// has no income tax, taxes only interest and dividends or taxes only capital gains. Deductions are rows of the state
+// id, filing status id, standard deduction, personal exemption, whether each is a credit, and the fallback rule
+// they are derived by. Filing statuses a state does not publish are derived by the rules of stateStatusFallbacks.
+// Footnotes are rows of the marker and text of each footnote of the sheet, and footnote cells are rows of the state
+// id, column and marker of each footnote marker of a state. An error is returned if the brackets of a filing status of a state do not
+// cover contiguous intervals with rising rates.
+func GetStateTaxData(workbook sourcefileutils.Workbook, nullString string) ([][]string, [][]string, [][]string, [][]string, [][]string, error) {
 
 	// read in the state individual file
-	stateTaxData, err := sourcefileutils.OpenExcelSheet(STATE_TAX_FILE, "2022")
+	stateTaxData, err := sourcefileutils.OpenSourceTable(workbook.FilePath, workbook.Sheet, stateColumns)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, nil, nil, err
 	}
 
 	// parse data structures
 	stateRates := [][]string{}
-	stateExcemptions := [][]string{}
+	states := [][]string{}
+	stateDeductions := [][]string{}
+	stateFootnotes := [][]string{}
+	stateFootnoteCells := [][]string{}
+	linked := make(map[string]bool)
 	stateId := nullString
-	for _, row := range stateTaxData {
-		// rows of length 12 are initial row for state, contain exemption
-		if len(row) == 12 {
-			// update state id, exemptions
-			if newStateId, ok := mp[strings.TrimSpace(strings.ToLower(row[0]))]; ok {
-
-				stateId = newStateId
-				stateExcemptions = append(stateExcemptions, []string{stateId, strings.TrimSpace(row[0]),
-					processDollarValue(row[7], nullString),
-					processDollarValue(row[8], nullString),
-					processDollarValue(row[9], nullString),
-					processDollarValue(row[10], nullString),
-					processDollarValue(row[11], nullString)})
-			}
+	for _, record := range stateTaxData {
+		// rows are told apart by the state cell. The footnote block below the table holds a footnote per row, i.e
+		// "(a) Local income taxes are excluded.", and ends the last state.
+		if marker, footnote, ok := parseFootnoteRow(record.Get("state")); ok {
+			stateId = nullString
+			stateFootnotes = append(stateFootnotes, []string{marker, footnote})
+			continue
 		}
 
-		// rows of length 12 also contain the first bracket information, and rows of length 8 are successive bracket information
-		if (len(row) == 12 || len(row) == 7) && stateId != nullString {
-			stateRates = append(stateRates, []string{stateId,
-				processRate(row[1], nullString),
-				processDollarValue(row[3], nullString),
-				processRate(row[4], nullString),
-				processDollarValue(row[6], nullString)})
+		// the first row of a state names the state and contains its exemptions. Footnote markers of the state, such
+		// as "(a, e)", may follow the name or fill the state cell of the next rows.
+		if state, _ := splitCellMarkers(record.Get("state")); state != "" {
+			// update state id, exemptions. Rows naming no known state end the previous state, and are reported if
+			// they give a rate as a state would.
+			stateId = nullString
+			if ref, ok := lookupState(state); ok {
+
+				stateId = ref.fips
+				states = append(states, append([]string{stateId, ref.name, ref.abbreviation,
+					processDollarValue(record.Get("exemption_dependent"), nullString), isCredit(record.Get("exemption_dependent"))},
+					getStateIncomeFlags(record.Get(stateFilerStatuses[0].rate))...))
+				for _, filer := range stateFilerStatuses {
+					// the deductions of an optional filing status are published only if one of its cells is given
+					if filer.optional && strings.TrimSpace(record.Get(filer.deduction)+record.Get(filer.exemption)) == "" {
+						continue
+					}
+					stateDeductions = append(stateDeductions, []string{stateId, filer.status,
+						processDollarValue(record.Get(filer.deduction), nullString),
+						processDollarValue(record.Get(filer.exemption), nullString),
+						isCredit(record.Get(filer.deduction)), isCredit(record.Get(filer.exemption)), nullString})
+				}
+			} else if strings.TrimSpace(record.Get(stateFilerStatuses[0].rate)) != "" {
+				logger.Warn("The state %s of the state sheet is not in the state reference, so it is not loaded", state)
+			}
+		}
 
+		// the first row of a state also contains the first bracket information, and successive rows with a rate for
+		// a filer are the following brackets of that filer
+		if stateId == nullString {
+			continue
 		}
+		stateFootnoteCells = appendFootnoteCells(stateFootnoteCells, linked, stateId, record)
+		for _, filer := range stateFilerStatuses {
+			if strings.TrimSpace(record.Get(filer.rate)) != "" {
+				stateRates = append(stateRates, []string{stateId, filer.status, "",
+					processRate(record.Get(filer.rate), nullString),
+					processDollarValue(record.Get(filer.bracket), nullString), "", nullString})
+			}
+		}
+
+	}
 
+	// the sheet gives only the threshold of each bracket, so each bracket ends where the next begins
+	setBracketIntervals(stateRates, 2, nullString)
+	// filing statuses a state does not publish are derived from those it does
+	stateRates = addStatusFallbacks(stateRates, []int{2 + bracketLower, 2 + bracketUpper}, nullString)
+	stateDeductions = addStatusFallbacks(stateDeductions, []int{2, 3}, nullString)
+	err = bracketValidationError("state", validateBracketIntervals(stateRates, 2, nullString))
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
 	}
 
-	return stateRates, stateExcemptions, nil
+	return stateRates, states, stateDeductions, stateFootnotes, dropUndefinedFootnotes(stateFootnoteCells, stateFootnotes), nil
 
 }
 
-// helper method with logic to process an exemption
+// helper method with logic to process a dollar value, such as a bracket, deduction or exemption. Returns the null
+// string if the cell holds no amount.
 func processDollarValue(ex, nullString string) string {
-	// get rid of everything not a number
-	reg, _ := regexp.Compile("[^0-9]+")
-	rep := reg.ReplaceAllString(ex, "")
+	// personal exemptions given as credits, i.e "$58 credit", are kept as the amount of the credit
+	ex = strings.Replace(ex, " credit", "", 1)
 
-	if rep == "" {
+	amount, err := parseutils.ParseAmount(ex)
+	if err != nil {
 		return nullString
-	} else {
-		return rep
 	}
+
+	return amount.String()
 }
 
-// process a tax rate
+// process a tax rate into a fraction. Returns the null string if the cell holds no rate, or a rate with a condition
+// such as "5% on interest and dividends only".
 func processRate(r, nullString string) string {
-	// trim spaces
-	r = strings.TrimSpace(r)
-	// trim %
-	r = strings.Trim(r, "%")
-	// If non number, return null string
-	_, err := strconv.ParseFloat(r, 64)
+	rate, err := parseutils.ParseRate(r)
 	if err != nil {
 		return nullString
-		// if r is empty return null
-	} else if r == "" {
-		return nullString
-	} else {
-		return r
 	}
+
+	return rate.String()
 }
@@ -22,24 +22,52 @@ var logger, _ = logging.GetLogger("file.log")
 // string constants
 const (
 	// table names
-	COUNTY             string = "county"
-	FEDERAL_DEDUCTIONS string = "federal_deductions"
-	FEDERAL_BRACKETS   string = "federal_brackets"
-	STATE_BRACKETS     string = "state_brackets"
-	STATE              string = "states"
-	TAX_JURISDICTION   string = "tax_locale"
+	COUNTY                        string = "county"
+	FEDERAL_DEDUCTIONS            string = "federal_deductions"
+	FEDERAL_BRACKETS              string = "federal_brackets"
+	FEDERAL_CAPITAL_GAINS         string = "federal_capital_gains_brackets"
+	FEDERAL_EITC                  string = "federal_eitc"
+	FEDERAL_ADDITIONAL_DEDUCTIONS string = "federal_additional_deductions"
+	FEDERAL_CHILD_TAX_CREDIT      string = "federal_child_tax_credit"
+	FEDERAL_PAYROLL_TAXES         string = "federal_payroll_taxes"
+	STATE_BRACKETS                string = "state_brackets"
+	STATE                         string = "states"
+	STATE_DEDUCTIONS              string = "state_deductions"
+	STATE_FOOTNOTES               string = "state_footnotes"
+	STATE_FOOTNOTE_CELLS          string = "state_footnote_cells"
+	FILING_STATUS                 string = "filing_status"
+	TAX_JURISDICTION              string = "tax_locale"
+	TAX_LOCALE_COUNTY             string = "tax_locale_county"
+	SOURCE_MANIFEST               string = "source_manifest"
+	ETL_RUN                       string = "etl_run"
 	// common sql file names
-	COUNTY_SQL            string = "county.sql"
-	FEDERAL_DEDUCTION_SQL string = "federal_deductions.sql"
-	FEDERAL_BRACKETS_SQL  string = "federal_brackets.sql"
-	STATE_BRACKETS_SQL    string = "state_brackets.sql"
-	STATE_SQL             string = "state.sql"
-	TAX_JURISDICION_SQL   string = "tax_locale.sql"
+	COUNTY_SQL                        string = "county.sql"
+	FEDERAL_DEDUCTION_SQL             string = "federal_deductions.sql"
+	FEDERAL_BRACKETS_SQL              string = "federal_brackets.sql"
+	FEDERAL_CAPITAL_GAINS_SQL         string = "federal_capital_gains_brackets.sql"
+	FEDERAL_EITC_SQL                  string = "federal_eitc.sql"
+	FEDERAL_ADDITIONAL_DEDUCTIONS_SQL string = "federal_additional_deductions.sql"
+	FEDERAL_CHILD_TAX_CREDIT_SQL      string = "federal_child_tax_credit.sql"
+	FEDERAL_PAYROLL_TAXES_SQL         string = "federal_payroll_taxes.sql"
+	STATE_BRACKETS_SQL                string = "state_brackets.sql"
+	STATE_SQL                         string = "state.sql"
+	STATE_DEDUCTIONS_SQL              string = "state_deductions.sql"
+	STATE_FOOTNOTES_SQL               string = "state_footnotes.sql"
+	STATE_FOOTNOTE_CELLS_SQL          string = "state_footnote_cells.sql"
+	FILING_STATUS_SQL                 string = "filing_status.sql"
+	TAX_JURISDICION_SQL               string = "tax_locale.sql"
+	TAX_LOCALE_COUNTY_SQL             string = "tax_locale_county.sql"
+	SOURCE_MANIFEST_SQL               string = "source_manifest.sql"
+	ETL_RUN_SQL                       string = "etl_run.sql"
 	// directories holding each type of SQL
-	DDL_DIR    string = "ddl"
-	INSERT_DIR string = "insert"
-	UPDATE_DIR string = "update"
-	VIEW_DIR   string = "view"
+	DDL_DIR     string = "ddl"
+	INSERT_DIR  string = "insert"
+	UPDATE_DIR  string = "update"
+	VIEW_DIR    string = "view"
+	MIGRATE_DIR string = "migrate"
+	// statuses of a stage in the etl run table
+	RUN_STARTED   string = "started"
+	RUN_SUCCEEDED string = "succeeded"
 	// ids of null county and state records
 	countyNullId string = "32767"
 	stateNullId  string = "32767"
@@ -51,7 +79,7 @@ type DbEngine struct {
 	// mapping of table names to sql script names common across all sql types (other than view)
 	sqlMap map[string]string
 	// mapping of table names to tables they are dependent on.
-	depMap map[string]string
+	depMap map[string][]string
 	// the null string expected for nulls in data inputs
 	nullString string
 }
@@ -59,23 +87,47 @@ type DbEngine struct {
 func NewDbEngine(nullString, dbUser, dbPassword, dbName, dbHost, dbPort string) (*DbEngine, error) {
 	// define the DDL map
 	sqlMap := map[string]string{
-		COUNTY:             COUNTY_SQL,
-		FEDERAL_DEDUCTIONS: FEDERAL_DEDUCTION_SQL,
-		FEDERAL_BRACKETS:   FEDERAL_BRACKETS_SQL,
-		STATE_BRACKETS:     STATE_BRACKETS_SQL,
-		STATE:              STATE_SQL,
-		TAX_JURISDICTION:   TAX_JURISDICION_SQL,
+		COUNTY:                        COUNTY_SQL,
+		FEDERAL_DEDUCTIONS:            FEDERAL_DEDUCTION_SQL,
+		FEDERAL_BRACKETS:              FEDERAL_BRACKETS_SQL,
+		FEDERAL_CAPITAL_GAINS:         FEDERAL_CAPITAL_GAINS_SQL,
+		FEDERAL_EITC:                  FEDERAL_EITC_SQL,
+		FEDERAL_ADDITIONAL_DEDUCTIONS: FEDERAL_ADDITIONAL_DEDUCTIONS_SQL,
+		FEDERAL_CHILD_TAX_CREDIT:      FEDERAL_CHILD_TAX_CREDIT_SQL,
+		FEDERAL_PAYROLL_TAXES:         FEDERAL_PAYROLL_TAXES_SQL,
+		STATE_BRACKETS:                STATE_BRACKETS_SQL,
+		STATE:                         STATE_SQL,
+		STATE_DEDUCTIONS:              STATE_DEDUCTIONS_SQL,
+		STATE_FOOTNOTES:               STATE_FOOTNOTES_SQL,
+		STATE_FOOTNOTE_CELLS:          STATE_FOOTNOTE_CELLS_SQL,
+		FILING_STATUS:                 FILING_STATUS_SQL,
+		TAX_JURISDICTION:              TAX_JURISDICION_SQL,
+		TAX_LOCALE_COUNTY:             TAX_LOCALE_COUNTY_SQL,
+		SOURCE_MANIFEST:               SOURCE_MANIFEST_SQL,
+		ETL_RUN:                       ETL_RUN_SQL,
 	}
 
 	// the dependency table. Map of tables to tables needed
 	// to create the table due to foriegn key constraints.
-	// at this time map to strings, may expand to map of
-	// lists if database expands in the future and needs
-	// a schema re-design
-	depMap := map[string]string{
-		COUNTY:           STATE,
-		STATE_BRACKETS:   STATE,
-		TAX_JURISDICTION: COUNTY,
+	// the bracket and deduction tables reference both their
+	// geography and the filing status table
+	depMap := map[string][]string{
+		COUNTY:               {STATE},
+		STATE_BRACKETS:       {STATE, FILING_STATUS},
+		STATE_DEDUCTIONS:     {STATE, FILING_STATUS},
+		STATE_FOOTNOTE_CELLS: {STATE, STATE_FOOTNOTES},
+		TAX_JURISDICTION:     {COUNTY},
+		TAX_LOCALE_COUNTY:    {TAX_JURISDICTION},
+		// tables without other dependencies reference the source manifest
+		STATE:                         {SOURCE_MANIFEST},
+		STATE_FOOTNOTES:               {SOURCE_MANIFEST},
+		FEDERAL_DEDUCTIONS:            {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_BRACKETS:              {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_CAPITAL_GAINS:         {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_EITC:                  {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_ADDITIONAL_DEDUCTIONS: {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_CHILD_TAX_CREDIT:      {SOURCE_MANIFEST, FILING_STATUS},
+		FEDERAL_PAYROLL_TAXES:         {SOURCE_MANIFEST, FILING_STATUS},
 	}
 
 	psqlInfo := fmt.Sprintf("host=%s port=%s user=%s "+
@@ -93,6 +145,11 @@ func NewDbEngine(nullString, dbUser, dbPassword, dbName, dbHost, dbPort string)
 	return &engine, nil
 }
 
+// method returning the database connection of the engine, for reading the loaded tables
+func (d *DbEngine) Connection() *sql.DB {
+	return d.con
+}
+
 // setup method used by each load. Checks if table exists, if not creates it. Also, will clear
 // given table if the provided flag is true.
 func (d *DbEngine) loadSetup(table string, c bool) error {
@@ -123,8 +180,8 @@ func (d *DbEngine) loadSetup(table string, c bool) error {
 
 // helper method to create a given table.
 func (d *DbEngine) createTable(table string) error {
-	// check if this table has depdencies, raise error if dependency does not already exist
-	if depTable, ok := d.depMap[table]; ok {
+	// check if this table has depdencies, raise error if a dependency does not already exist
+	for _, depTable := range d.depMap[table] {
 		tableExists, err := d.doesTableExist(depTable)
 		if err != nil {
 			logger.Warn("Unable to retrieve if the depedency table %s exists. Proceeding with load", depTable)
@@ -212,14 +269,8 @@ func (d *DbEngine) readFileFromSqlDir(filePath string) (string, error) {
 
 // helper method to execute an insert query
 func (d *DbEngine) executeInsertStatement(query string, vals []interface{}, records int) error {
-	// ? -> $n for postgres
-	paramCount := strings.Count(query, "?")
-	for n := 1; n <= paramCount; n++ {
-		query = strings.Replace(query, "?", "$"+strconv.Itoa(n), 1)
-	}
-
 	// format all vals at once
-	_, err := d.con.Exec(query, vals...)
+	_, err := d.con.Exec(toPostgresParams(query), vals...)
 	if err != nil {
 		return err
 	}
@@ -229,6 +280,16 @@ func (d *DbEngine) executeInsertStatement(query string, vals []interface{}, reco
 	return nil
 }
 
+// helper method to number the ? params of a query as $n for postgres
+func toPostgresParams(query string) string {
+	paramCount := strings.Count(query, "?")
+	for n := 1; n <= paramCount; n++ {
+		query = strings.Replace(query, "?", "$"+strconv.Itoa(n), 1)
+	}
+
+	return query
+}
+
 // helper method to convert empty strings + null strings to string representing nil int
 func (d *DbEngine) NewNullIntStr(s string) string {
 	nilInt := "0"
@@ -253,6 +314,16 @@ func (d *DbEngine) NewNullDecStr(s string) string {
 	return s
 }
 
+// helper method to convert empty strings + null strings to nulls for text columns, and nullable columns of other
+// types which postgres parses from text
+func (d *DbEngine) newNullStr(s string) sql.NullString {
+	if len(s) == 0 || s == d.nullString {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: s, Valid: true}
+}
+
 // helper method to convert empty strings + null strings to nulls
 func (d *DbEngine) NewNullFloat(s string) sql.NullFloat64 {
 	if len(s) == 0 {
@@ -268,7 +339,7 @@ func (d *DbEngine) NewNullFloat(s string) sql.NullFloat64 {
 }
 
 // public method to create the county table
-func (d *DbEngine) LoadCountyTable(data [][]string, c bool) error {
+func (d *DbEngine) LoadCountyTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for county table")
 	err := d.loadSetup(COUNTY, c)
 	if err != nil {
@@ -279,7 +350,7 @@ func (d *DbEngine) LoadCountyTable(data [][]string, c bool) error {
 	if err != nil {
 		return err
 	}
-	vals := []interface{}{countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId}
+	vals := []interface{}{countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, countyNullId, manifestId}
 
 	for _, row := range data {
 		// county id is state concated with the county id from the input data
@@ -293,8 +364,8 @@ func (d *DbEngine) LoadCountyTable(data [][]string, c bool) error {
 			state_id = row[7]
 		}
 
-		query += "(?, ?, ?, ?, ?, ?, ?, ?, ?), "
-		vals = append(vals, county_id, row[0], state_id, row[1], row[2], row[3], row[4], row[5], d.NewNullIntStr(row[6]))
+		query += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, county_id, row[0], state_id, row[1], row[2], row[3], row[4], row[5], d.NewNullIntStr(row[6]), manifestId)
 	}
 
 	// append the update SQL
@@ -313,7 +384,7 @@ func (d *DbEngine) LoadCountyTable(data [][]string, c bool) error {
 }
 
 // method to create the local tax jurisdiction table
-func (d *DbEngine) LoadLocalTaxTable(data [][]string, c bool) error {
+func (d *DbEngine) LoadLocalTaxTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for local tax table")
 	err := d.loadSetup(TAX_JURISDICTION, c)
 	if err != nil {
@@ -325,9 +396,9 @@ func (d *DbEngine) LoadLocalTaxTable(data [][]string, c bool) error {
 		return err
 	}
 
-	// Psql has a max 65535 params per query. With 15 params per row, a max of 4369 rows can be inserted per
+	// Psql has a max 65535 params per query. With 27 params per row, a max of 2427 rows can be inserted per
 	// insert query.
-	maxDataPartSize := 4369
+	maxDataPartSize := 2427
 	start := 0
 	moreData := true
 	dataSize := len(data)
@@ -340,7 +411,7 @@ func (d *DbEngine) LoadLocalTaxTable(data [][]string, c bool) error {
 			moreData = false
 		}
 		// process this part
-		err = d.loadLocalTaxPart(dataPart, query, start)
+		err = d.loadLocalTaxPart(dataPart, manifestId, query)
 		if err != nil {
 			return err
 		}
@@ -352,24 +423,26 @@ func (d *DbEngine) LoadLocalTaxTable(data [][]string, c bool) error {
 }
 
 // helper method to load a portion of the local tax table due to Postgresql parameter constraints
-func (d *DbEngine) loadLocalTaxPart(data [][]string, query string, pos int) error {
+func (d *DbEngine) loadLocalTaxPart(data [][]string, manifestId string, query string) error {
 	vals := []interface{}{}
 	for _, row := range data {
-		query += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?), "
-		// if county id is NONE, set to null county id
-		var county_id string
-		if row[1] == d.nullString {
-			county_id = countyNullId
-		} else {
-			county_id = row[1]
+		query += "(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?), "
+		// jurisdictions that could not be matched to a county or state are stored with nulls
+		var state_id, county_id interface{}
+		if row[2] != d.nullString {
+			state_id = row[2]
+		}
+		if row[4] != d.nullString {
+			county_id = row[4]
 		}
 
-		vals = append(vals, fmt.Sprint(pos), row[0], county_id, row[2], d.NewNullDecStr(row[3]), d.NewNullDecStr(row[4]), d.NewNullDecStr(row[5]), d.NewNullDecStr(row[6]),
-			d.NewNullDecStr(row[7]), row[8], d.NewNullDecStr(row[9]), d.NewNullDecStr(row[10]),
-			d.NewNullDecStr(row[11]), d.NewNullDecStr(row[12]), d.NewNullDecStr(row[13]))
-
-		// increment the position
-		pos = pos + 1
+		vals = append(vals, row[0], row[1], row[3], state_id, county_id, row[5])
+		// resident fields followed by non-resident fields
+		for _, attr := range [][]string{row[6:16], row[16:26]} {
+			vals = append(vals, attr[0], d.NewNullDecStr(attr[1]), d.NewNullDecStr(attr[2]), d.NewNullDecStr(attr[3]), d.NewNullDecStr(attr[4]),
+				d.NewNullDecStr(attr[5]), d.NewNullDecStr(attr[6]), d.NewNullDecStr(attr[7]), d.NewNullDecStr(attr[8]), d.newNullStr(attr[9]))
+		}
+		vals = append(vals, manifestId)
 
 	}
 
@@ -386,8 +459,130 @@ func (d *DbEngine) loadLocalTaxPart(data [][]string, query string, pos int) erro
 	return d.executeInsertStatement(query, vals, len(data))
 }
 
+// method returning the ids of the loaded tax locales by their natural key, so reloads keep the id of each
+// jurisdiction. Empty if the table has not been created.
+func (d *DbEngine) TaxLocaleIds() (map[string]int32, error) {
+	ids := make(map[string]int32)
+	tableExists, err := d.doesTableExist(TAX_JURISDICTION)
+	if err != nil || !tableExists {
+		return ids, err
+	}
+
+	rows, err := d.con.Query("SELECT tax_locale_key, tax_locale_id FROM tax_locale;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var id int32
+		err = rows.Scan(&key, &id)
+		if err != nil {
+			return nil, err
+		}
+		ids[key] = id
+	}
+
+	return ids, rows.Err()
+}
+
+// method to create the table linking local tax jurisdictions to each county they apply to. The links of every loaded
+// tax locale are replaced, so counties a locale no longer applies to are dropped, even if it is now linked to none.
+func (d *DbEngine) LoadLocalTaxCountyTable(localeIds []string, data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for local tax county table")
+	err := d.loadSetup(TAX_LOCALE_COUNTY, c)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(TAX_LOCALE_COUNTY, "insert")
+	if err != nil {
+		return err
+	}
+
+	updateSql, err := d.readSQLFileAsString(TAX_LOCALE_COUNTY, "update")
+	if err != nil {
+		return err
+	}
+
+	// delete the stale links and insert the new ones together
+	tx, err := d.con.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Psql has a max 65535 params per query. With 4 params per row, a max of 16383 rows can be inserted per
+	// insert query, and the links of as many locales deleted per delete query.
+	maxDataPartSize := 16383
+	for start := 0; start < len(localeIds); start += maxDataPartSize {
+		end := start + maxDataPartSize
+		if end > len(localeIds) {
+			end = len(localeIds)
+		}
+
+		deleteQuery := "DELETE FROM tax_locale_county WHERE tax_locale_id IN ("
+		deleteVals := []interface{}{}
+		for _, id := range localeIds[start:end] {
+			deleteQuery += "?, "
+			deleteVals = append(deleteVals, id)
+		}
+		deleteQuery = strings.TrimSuffix(deleteQuery, ", ") + ");"
+
+		_, err = tx.Exec(toPostgresParams(deleteQuery), deleteVals...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	for start := 0; start < len(data); start += maxDataPartSize {
+		end := start + maxDataPartSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		err = d.loadLocalTaxCountyPart(tx, data[start:end], manifestId, query, updateSql)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Successfully upserted data for %v records.", len(data))
+
+	return nil
+}
+
+// helper method to load a portion of the local tax county table within the transaction replacing the links
+func (d *DbEngine) loadLocalTaxCountyPart(tx *sql.Tx, data [][]string, manifestId string, query string, updateSql string) error {
+	vals := []interface{}{}
+	for _, row := range data {
+		query += "(?, ?, ?, ?), "
+		// the population share is null if unknown
+		var share interface{}
+		if row[2] != d.nullString {
+			share = row[2]
+		}
+		vals = append(vals, row[0], row[1], share, manifestId)
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	_, err := tx.Exec(toPostgresParams(query), vals...)
+
+	return err
+}
+
 // method to create the state table
-func (d *DbEngine) LoadStateTable(data [][]string, c bool) error {
+func (d *DbEngine) LoadStateTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for state table")
 	err := d.loadSetup(STATE, c)
 	if err != nil {
@@ -398,11 +593,11 @@ func (d *DbEngine) LoadStateTable(data [][]string, c bool) error {
 	if err != nil {
 		return err
 	}
-	vals := []interface{}{stateNullId, stateNullId, stateNullId, stateNullId, stateNullId, stateNullId, stateNullId}
+	vals := []interface{}{stateNullId, stateNullId, nil, stateNullId, "false", "false", "false", "false", manifestId}
 
 	for _, row := range data {
-		query += "(?, ?, ?, ?, ?, ?, ?), "
-		vals = append(vals, row[0], row[1], d.NewNullIntStr(row[2]), d.NewNullIntStr(row[3]), d.NewNullIntStr(row[4]), d.NewNullIntStr(row[5]), d.NewNullIntStr(row[6]))
+		query += "(?, ?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], d.NewNullIntStr(row[3]), row[4], row[5], row[6], row[7], manifestId)
 	}
 
 	updateSql, err := d.readSQLFileAsString(STATE, "update")
@@ -418,8 +613,9 @@ func (d *DbEngine) LoadStateTable(data [][]string, c bool) error {
 	return d.executeInsertStatement(query, vals, len(data))
 }
 
-// method to create the state bracket table
-func (d *DbEngine) LoadStateBracketTable(data [][]string, c bool) error {
+// method to create the state bracket table. The brackets of each state and filing status loaded replace those
+// already stored, so brackets above a new top bracket do not survive a schedule that loses brackets
+func (d *DbEngine) LoadStateBracketTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for state bracket table")
 	err := d.loadSetup(STATE_BRACKETS, c)
 	if err != nil {
@@ -432,9 +628,21 @@ func (d *DbEngine) LoadStateBracketTable(data [][]string, c bool) error {
 	}
 	vals := []interface{}{}
 
+	deleteQuery := "DELETE FROM state_brackets WHERE (state_id, filing_status_id) IN ("
+	deleteVals := []interface{}{}
+	schedules := map[string]bool{}
+
 	for _, row := range data {
-		query += "(?, ?, ?, ?, ?), "
-		vals = append(vals, row[0], d.NewNullDecStr(row[1]), d.NewNullIntStr(row[2]), d.NewNullDecStr(row[3]), d.NewNullIntStr(row[4]))
+		query += "(?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], d.NewNullDecStr(row[3]), d.NewNullIntStr(row[4]), d.newNullStr(row[5]), d.newNullStr(row[6]), manifestId)
+
+		// each state and filing status loaded is deleted once
+		schedule := row[0] + ":" + row[1]
+		if !schedules[schedule] {
+			schedules[schedule] = true
+			deleteQuery += "(?, ?), "
+			deleteVals = append(deleteVals, row[0], row[1])
+		}
 	}
 
 	updateSql, err := d.readSQLFileAsString(STATE_BRACKETS, "update")
@@ -446,12 +654,139 @@ func (d *DbEngine) LoadStateBracketTable(data [][]string, c bool) error {
 	query += " "
 	query += updateSql
 
+	deleteQuery = strings.TrimSuffix(deleteQuery, ", ") + ");"
+
+	// delete the stale brackets and insert the new ones together
+	tx, err := d.con.Begin()
+	if err != nil {
+		return err
+	}
+
+	if len(deleteVals) > 0 {
+		_, err = tx.Exec(toPostgresParams(deleteQuery), deleteVals...)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec(toPostgresParams(query), vals...)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Successfully upserted data for %v records.", len(data))
+
+	return nil
+}
+
+// method to create the state deductions table, holding the standard deduction and personal exemption of each filing
+// status of each state
+func (d *DbEngine) LoadStateDeductionTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for state deduction table")
+	err := d.loadSetup(STATE_DEDUCTIONS, c)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(STATE_DEDUCTIONS, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], d.NewNullIntStr(row[2]), d.NewNullIntStr(row[3]), row[4], row[5], d.newNullStr(row[6]), manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(STATE_DEDUCTIONS, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
 	// execute the formed insert statement
 	return d.executeInsertStatement(query, vals, len(data))
 }
 
-// method to create the federal deductions table
-func (d *DbEngine) LoadFederalDeductionsTable(data []string, c bool) error {
+// method to create the state footnotes table, holding the text of each footnote of the state sheet. A sheet without
+// footnotes loads no rows.
+func (d *DbEngine) LoadStateFootnoteTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for state footnote table")
+	err := d.loadSetup(STATE_FOOTNOTES, c)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(STATE_FOOTNOTES, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?), "
+		vals = append(vals, row[0], row[1], manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(STATE_FOOTNOTES, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the state footnote cells table, linking the footnote markers of the state sheet to the column of
+// the state they mark
+func (d *DbEngine) LoadStateFootnoteCellTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for state footnote cell table")
+	err := d.loadSetup(STATE_FOOTNOTE_CELLS, c)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(STATE_FOOTNOTE_CELLS, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(STATE_FOOTNOTE_CELLS, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the federal deductions table, keyed by tax year
+func (d *DbEngine) LoadFederalDeductionsTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for federal deductions table")
 	err := d.loadSetup(FEDERAL_DEDUCTIONS, c)
 	if err != nil {
@@ -464,8 +799,10 @@ func (d *DbEngine) LoadFederalDeductionsTable(data []string, c bool) error {
 	}
 	vals := []interface{}{}
 
-	query += "(?, ?, ?), "
-	vals = append(vals, data[0], data[1], data[2])
+	for _, row := range data {
+		query += "(?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], manifestId)
+	}
 
 	updateSql, err := d.readSQLFileAsString(FEDERAL_DEDUCTIONS, "update")
 	if err != nil {
@@ -480,7 +817,7 @@ func (d *DbEngine) LoadFederalDeductionsTable(data []string, c bool) error {
 }
 
 // method to create the federal brackets table
-func (d *DbEngine) LoadFederalBracketTable(data [][]string, c bool) error {
+func (d *DbEngine) LoadFederalBracketTable(data [][]string, manifestId string, c bool) error {
 	logger.Info("Executing insert for federal bracket table")
 	err := d.loadSetup(FEDERAL_BRACKETS, c)
 	if err != nil {
@@ -494,8 +831,9 @@ func (d *DbEngine) LoadFederalBracketTable(data [][]string, c bool) error {
 	vals := []interface{}{}
 
 	for _, row := range data {
-		query += "(?, ?, ?, ?), "
-		vals = append(vals, row[0], row[1], row[2], row[3])
+		query += "(?, ?, ?, ?, ?, ?, ?), "
+		// the top bracket has a null upper bound
+		vals = append(vals, row[0], row[1], row[2], row[3], row[4], d.newNullStr(row[5]), manifestId)
 	}
 
 	updateSql, err := d.readSQLFileAsString(FEDERAL_BRACKETS, "update")
@@ -511,6 +849,314 @@ func (d *DbEngine) LoadFederalBracketTable(data [][]string, c bool) error {
 	return d.executeInsertStatement(query, vals, len(data))
 }
 
+// method to create the federal long term capital gains brackets table, keyed by tax year
+func (d *DbEngine) LoadFederalCapitalGainsTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for federal capital gains bracket table")
+	err := d.loadSetup(FEDERAL_CAPITAL_GAINS, c)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FEDERAL_CAPITAL_GAINS, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?, ?, ?, ?), "
+		// the top bracket has a null upper bound
+		vals = append(vals, row[0], row[1], row[2], row[3], row[4], d.newNullStr(row[5]), manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(FEDERAL_CAPITAL_GAINS, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the federal earned income tax credit table, keyed by tax year
+func (d *DbEngine) LoadFederalEitcTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for federal earned income tax credit table")
+	err := d.loadSetup(FEDERAL_EITC, c)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FEDERAL_EITC, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], row[3], row[4], row[5], row[6], manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(FEDERAL_EITC, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the federal additional standard deductions table, keyed by tax year. No IRS parameters loads no
+// rows.
+func (d *DbEngine) LoadFederalAdditionalDeductionTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for federal additional deduction table")
+	err := d.loadSetup(FEDERAL_ADDITIONAL_DEDUCTIONS, c)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FEDERAL_ADDITIONAL_DEDUCTIONS, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(FEDERAL_ADDITIONAL_DEDUCTIONS, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the federal child tax credit table, keyed by tax year. No IRS parameters loads no rows.
+func (d *DbEngine) LoadFederalChildTaxCreditTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for federal child tax credit table")
+	err := d.loadSetup(FEDERAL_CHILD_TAX_CREDIT, c)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FEDERAL_CHILD_TAX_CREDIT, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?, ?, ?, ?, ?), "
+		vals = append(vals, row[0], row[1], row[2], row[3], row[4], row[5], row[6], manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(FEDERAL_CHILD_TAX_CREDIT, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the federal payroll taxes table, keyed by tax year. No IRS parameters loads no rows.
+func (d *DbEngine) LoadFederalPayrollTaxTable(data [][]string, manifestId string, c bool) error {
+	logger.Info("Executing insert for federal payroll tax table")
+	err := d.loadSetup(FEDERAL_PAYROLL_TAXES, c)
+	if err != nil || len(data) == 0 {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FEDERAL_PAYROLL_TAXES, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?, ?, ?, ?, ?, ?, ?), "
+		// taxes on all wages have a null wage base, and taxes from the first dollar a null threshold
+		vals = append(vals, row[0], row[1], row[2], row[3], row[4], d.newNullStr(row[5]), d.newNullStr(row[6]), manifestId)
+	}
+
+	updateSql, err := d.readSQLFileAsString(FEDERAL_PAYROLL_TAXES, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to create the filing status lookup table referenced by the bracket and deduction tables. The table is never
+// cleared, as clearing it would clear the tables referencing it.
+func (d *DbEngine) LoadFilingStatusTable(data [][]string) error {
+	logger.Info("Executing insert for filing status table")
+	err := d.loadSetup(FILING_STATUS, false)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(FILING_STATUS, "insert")
+	if err != nil {
+		return err
+	}
+	vals := []interface{}{}
+
+	for _, row := range data {
+		query += "(?, ?), "
+		vals = append(vals, row[0], row[1])
+	}
+
+	updateSql, err := d.readSQLFileAsString(FILING_STATUS, "update")
+	if err != nil {
+		return err
+	}
+
+	query = strings.TrimSuffix(query, ", ")
+	query += " "
+	query += updateSql
+
+	// execute the formed insert statement
+	return d.executeInsertStatement(query, vals, len(data))
+}
+
+// method to record a source in the source manifest, returning the id of its entry. The manifest is never cleared,
+// as it is the history of the sources loaded. The manifest holds the run id, source type, source url, source path,
+// published year, tax year, checksum and license, in that order.
+func (d *DbEngine) LoadSourceManifest(manifest []string) (string, error) {
+	logger.Info("Recording the %s source in the source manifest", manifest[1])
+	err := d.loadSetup(SOURCE_MANIFEST, false)
+	if err != nil {
+		return "", err
+	}
+
+	query, err := d.readSQLFileAsString(SOURCE_MANIFEST, "insert")
+	if err != nil {
+		return "", err
+	}
+
+	updateSql, err := d.readSQLFileAsString(SOURCE_MANIFEST, "update")
+	if err != nil {
+		return "", err
+	}
+
+	query += "(?, ?, ?, ?, ?, ?, ?, ?) " + updateSql
+	vals := []interface{}{}
+	for _, v := range manifest {
+		vals = append(vals, v)
+	}
+
+	var manifestId string
+	err = d.con.QueryRow(toPostgresParams(query), vals...).Scan(&manifestId)
+	if err != nil {
+		return "", err
+	}
+
+	return manifestId, nil
+}
+
+// method returning the source checksum of the last successful run of a stage, empty if the stage has not succeeded
+func (d *DbEngine) LastStageChecksum(stage string) (string, error) {
+	err := d.loadSetup(ETL_RUN, false)
+	if err != nil {
+		return "", err
+	}
+
+	query :=
+		`SELECT source_checksum
+		FROM etl_run
+		WHERE stage = $1 AND status = $2
+		ORDER BY finished_at DESC
+		LIMIT 1;`
+	var checksum string
+	err = d.con.QueryRow(query, stage, RUN_SUCCEEDED).Scan(&checksum)
+	if err == sql.ErrNoRows {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	return checksum, nil
+}
+
+// method to record the start of a stage of a run along with the checksum of its sources. A stage that does not
+// finish is left as started.
+func (d *DbEngine) StartStageRun(runId, stage, checksum string) error {
+	err := d.loadSetup(ETL_RUN, false)
+	if err != nil {
+		return err
+	}
+
+	query, err := d.readSQLFileAsString(ETL_RUN, "insert")
+	if err != nil {
+		return err
+	}
+
+	updateSql, err := d.readSQLFileAsString(ETL_RUN, "update")
+	if err != nil {
+		return err
+	}
+
+	query += "(?, ?, ?, ?) " + updateSql
+	_, err = d.con.Exec(toPostgresParams(query), runId, stage, checksum, RUN_STARTED)
+
+	return err
+}
+
+// method to record that a stage of a run has loaded successfully
+func (d *DbEngine) FinishStageRun(runId, stage string) error {
+	query := "UPDATE etl_run SET status = $1, finished_at = NOW() WHERE run_id = $2 AND stage = $3;"
+	_, err := d.con.Exec(query, RUN_SUCCEEDED, runId, stage)
+
+	return err
+}
+
+// method to forget the runs of the given stages, so their next run is not skipped. Used when the tables of the stages
+// are emptied by clearing a table they reference.
+func (d *DbEngine) ResetStageRuns(stages []string) error {
+	err := d.loadSetup(ETL_RUN, false)
+	if err != nil {
+		return err
+	}
+
+	query := "DELETE FROM etl_run WHERE stage IN ("
+	vals := []interface{}{}
+	for _, stage := range stages {
+		query += "?, "
+		vals = append(vals, stage)
+	}
+	query = strings.TrimSuffix(query, ", ") + ");"
+
+	_, err = d.con.Exec(toPostgresParams(query), vals...)
+
+	return err
+}
+
 // public method used to refresh all views
 func (d *DbEngine) RefreshViews() error {
 
@@ -542,3 +1188,74 @@ func (d *DbEngine) RefreshViews() error {
 
 	return nil
 }
+
+// public method used to apply the migrations in the migrate directory that have not been applied yet, in order
+// of file name. Migrations bring tables created by earlier versions of the ETL up to date with the DDL.
+func (d *DbEngine) Migrate() error {
+	_, err := d.con.Exec(
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			migration VARCHAR( 100 ) PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL DEFAULT NOW()
+		);`)
+	if err != nil {
+		return err
+	}
+
+	_, filename, _, ok := runtime.Caller(0)
+	if !ok {
+		return fmt.Errorf("No caller information")
+	}
+
+	migrateDir := path.Dir(filename) + "/sql/" + MIGRATE_DIR + "/"
+
+	// read dir returns the migrations sorted by file name
+	migrations, err := ioutil.ReadDir(migrateDir)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range migrations {
+		var applied bool
+		row := d.con.QueryRow("SELECT EXISTS (SELECT FROM schema_migrations WHERE migration = $1);", migration.Name())
+		err = row.Scan(&applied)
+		if err != nil {
+			return err
+		}
+
+		if applied {
+			continue
+		}
+
+		migrationQuery, err := d.readFileFromSqlDir(MIGRATE_DIR + "/" + migration.Name())
+		if err != nil {
+			return err
+		}
+
+		// apply the migration and record it together
+		tx, err := d.con.Begin()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(migrationQuery)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Migration %s failed: %s", migration.Name(), err)
+		}
+
+		_, err = tx.Exec("INSERT INTO schema_migrations (migration) VALUES ($1);", migration.Name())
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+
+		logger.Info("Successfully applied migration %s", migration.Name())
+	}
+
+	return nil
+}
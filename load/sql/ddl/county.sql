@@ -16,5 +16,12 @@ CREATE TABLE county (
     median_income BIGINT NOT NULL,
     average_rent BIGINT NOT NULL, 
     -- all metrics are not null. Use zero value in load if not applicable.
-    commute INTEGER NOT NULL
+    commute INTEGER NOT NULL,
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
 );
\ No newline at end of file
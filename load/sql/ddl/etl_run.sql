@@ -0,0 +1,11 @@
+CREATE TABLE etl_run (
+    run_id VARCHAR( 40 ) NOT NULL,
+    stage VARCHAR( 10 ) NOT NULL,
+    -- checksum over the checksums of the stage's sources and the settings it ran with
+    source_checksum CHAR( 64 ) NOT NULL,
+    -- started when the stage begins loading, succeeded once it has loaded
+    status VARCHAR( 20 ) NOT NULL,
+    started_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMP,
+    CONSTRAINT ux_etl_run UNIQUE (run_id, stage)
+);
\ No newline at end of file
@@ -0,0 +1,20 @@
+CREATE TABLE federal_additional_deductions (
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    -- additional standard deduction for each condition of age 65 or over or blindness, of the filer and of a spouse
+    deduction INTEGER NOT NULL,
+    CONSTRAINT ux_federal_additional_deductions UNIQUE (tax_year, filing_status_id),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
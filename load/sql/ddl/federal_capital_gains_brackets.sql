@@ -0,0 +1,25 @@
+CREATE TABLE federal_capital_gains_brackets (
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    -- position of the bracket among those of its filing status, from 1 for the lowest
+    ordinal SMALLINT NOT NULL,
+    -- rates of long term capital gains and qualified dividends are fractions, i.e 0.15 for 15%
+    rate DECIMAL NOT NULL,
+    lower_bound INTEGER NOT NULL,
+    -- null for the top bracket
+    upper_bound INTEGER,
+    CONSTRAINT ux_federal_capital_gains_brackets UNIQUE (tax_year, filing_status_id, ordinal),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE federal_child_tax_credit (
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    credit_per_child INTEGER NOT NULL,
+    -- the most of the credit per child refunded as the additional child tax credit
+    refundable_per_child INTEGER NOT NULL,
+    -- credit for other dependents, such as children 17 or over
+    other_dependent_credit INTEGER NOT NULL,
+    -- income over which the credit phases out, by the phaseout rate of the income over it
+    phaseout_threshold INTEGER NOT NULL,
+    phaseout_rate DECIMAL NOT NULL,
+    CONSTRAINT ux_federal_child_tax_credit UNIQUE (tax_year, filing_status_id),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
@@ -1,6 +1,19 @@
 CREATE TABLE federal_deductions (
-    single_deduction SMALLINT NOT NULL, 
-    married_deduction SMALLINT NOT NULL, 
-    head_deduction SMALLINT NOT NULL,
-    CONSTRAINT ux_deductions UNIQUE (single_deduction, married_deduction, head_deduction)
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    deduction INTEGER NOT NULL, 
+    CONSTRAINT ux_federal_deductions UNIQUE (tax_year, filing_status_id),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
 );
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE federal_eitc (
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    -- qualifying children, the most counted standing for that many or more
+    children SMALLINT NOT NULL,
+    -- earned income the credit reaches its maximum at
+    income_at_max_credit INTEGER NOT NULL,
+    max_credit INTEGER NOT NULL,
+    -- incomes the credit starts to phase out at and reaches zero at
+    phaseout_begins INTEGER NOT NULL,
+    phaseout_ends INTEGER NOT NULL,
+    CONSTRAINT ux_federal_eitc UNIQUE (tax_year, filing_status_id, children),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
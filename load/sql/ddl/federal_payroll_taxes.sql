@@ -0,0 +1,27 @@
+CREATE TABLE federal_payroll_taxes (
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    tax_year SMALLINT NOT NULL,
+    filing_status_id SMALLINT NOT NULL,
+    -- the payroll tax, i.e social security, medicare or additional medicare
+    payroll_tax VARCHAR( 40 ) NOT NULL,
+    -- rates are fractions, i.e 0.062 for 6.2%
+    employee_rate DECIMAL NOT NULL,
+    employer_rate DECIMAL NOT NULL,
+    -- wages the tax applies up to, null if the tax applies to all wages
+    wage_base INTEGER,
+    -- wages the tax applies above, null if the tax applies from the first dollar
+    threshold INTEGER,
+    CONSTRAINT ux_federal_payroll_taxes UNIQUE (tax_year, filing_status_id, payroll_tax),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+CREATE TABLE filing_status (
+    filing_status_id SMALLINT PRIMARY KEY,
+    filing_status VARCHAR( 40 ) NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,17 @@
+CREATE TABLE source_manifest (
+    manifest_id SERIAL PRIMARY KEY,
+    -- id of the ETL run that loaded the source
+    run_id VARCHAR( 40 ) NOT NULL,
+    -- type of source, one of federal, state, local, census or irs parameters
+    source_type VARCHAR( 20 ) NOT NULL,
+    source_url VARCHAR( 300 ) NOT NULL,
+    -- path of the workbook read, or the query made to the census API without its key
+    source_path VARCHAR( 300 ) NOT NULL,
+    published_year SMALLINT NOT NULL,
+    tax_year SMALLINT NOT NULL,
+    -- hex encoded SHA-256 checksum of the workbook or API response
+    checksum CHAR( 64 ) NOT NULL,
+    license TEXT NOT NULL,
+    loaded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ux_source_manifest UNIQUE (run_id, source_type)
+);
\ No newline at end of file
@@ -1,10 +1,21 @@
 CREATE TABLE states (
     state_id SMALLINT PRIMARY KEY,
     state_name VARCHAR ( 50 ) NOT NULL,
-    -- all metrics are not null. Use zero value in load if not applicable.
-    single_deduction INTEGER NOT NULL,
-    married_deduction INTEGER NOT NULL,
-    single_exemption SMALLINT NOT NULL,
-    married_exemption SMALLINT NOT NULL,
-    dependent_exemption SMALLINT NOT NULL
+    -- the USPS code of the state, null for the default state record
+    abbreviation CHAR ( 2 ),
+    -- all metrics are not null. Use zero value in load if not applicable. Deductions and exemptions of each
+    -- filing status are in the state deductions table.
+    dependent_exemption INTEGER NOT NULL,
+    -- flags of the special cases the sheet gives as text, such as "$29 credit" or "none"
+    dependent_exemption_credit BOOLEAN NOT NULL DEFAULT FALSE,
+    no_income_tax BOOLEAN NOT NULL DEFAULT FALSE,
+    interest_dividends_only BOOLEAN NOT NULL DEFAULT FALSE,
+    capital_gains_only BOOLEAN NOT NULL DEFAULT FALSE,
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
 );
\ No newline at end of file
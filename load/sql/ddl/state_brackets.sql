@@ -6,10 +6,29 @@ CREATE TABLE state_brackets (
         ON DELETE CASCADE,
     
     state_id SMALLINT NOT NULL,
-    -- all metrics are not null. Use zero value in load if not applicable.
-    single_rate DECIMAL NOT NULL,
-    single_bracket INTEGER NOT NULL,
-    married_rate DECIMAL NOT NULL,
-    married_bracket INTEGER NOT NULL,
-    CONSTRAINT ux_state_brackets UNIQUE (state_id, single_bracket, married_bracket)
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    filing_status_id SMALLINT NOT NULL,
+    -- position of the bracket among those of its state and filing status, from 1 for the lowest
+    ordinal SMALLINT NOT NULL,
+    -- all metrics other than the upper bound are not null. Use zero value in load if not applicable.
+    rate DECIMAL NOT NULL,
+    lower_bound INTEGER NOT NULL,
+    -- null for the top bracket
+    upper_bound INTEGER,
+    -- the rule the bracket is derived by if the state does not publish the schedule of the filing status, i.e
+    -- "half married filing jointly", null if published
+    fallback_rule VARCHAR( 40 ),
+    CONSTRAINT ux_state_filing_brackets UNIQUE (state_id, filing_status_id, ordinal),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
 );
\ No newline at end of file
@@ -0,0 +1,33 @@
+CREATE TABLE state_deductions (
+    -- the state id is a foriegn key for the state table
+    CONSTRAINT fk_state
+        FOREIGN KEY(state_id) 
+	    REFERENCES states(state_id)
+        ON DELETE CASCADE,
+    
+    state_id SMALLINT NOT NULL,
+    -- the filing status id is a foriegn key for the filing status table
+    CONSTRAINT fk_filing_status
+        FOREIGN KEY(filing_status_id) 
+	    REFERENCES filing_status(filing_status_id)
+        ON DELETE CASCADE,
+
+    filing_status_id SMALLINT NOT NULL,
+    -- all metrics are not null. Use zero value in load if not applicable.
+    deduction INTEGER NOT NULL,
+    exemption INTEGER NOT NULL,
+    -- whether the deduction or exemption is given as a tax credit, i.e "$58 credit"
+    deduction_credit BOOLEAN NOT NULL DEFAULT FALSE,
+    exemption_credit BOOLEAN NOT NULL DEFAULT FALSE,
+    -- the rule the deduction and exemption are derived by if the state does not publish them for the filing status,
+    -- i.e "single", null if published
+    fallback_rule VARCHAR( 40 ),
+    CONSTRAINT ux_state_deductions UNIQUE (state_id, filing_status_id),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
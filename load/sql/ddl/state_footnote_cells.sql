@@ -0,0 +1,26 @@
+CREATE TABLE state_footnote_cells (
+    -- the state id is a foriegn key for the state table
+    CONSTRAINT fk_state
+        FOREIGN KEY(state_id) 
+	    REFERENCES states(state_id)
+        ON DELETE CASCADE,
+    
+    state_id SMALLINT NOT NULL,
+    -- the column of the state sheet the marker is in, i.e "state" or "exemption_dependent"
+    column_name VARCHAR( 40 ) NOT NULL,
+    -- the marker is a foriegn key for the state footnotes table
+    CONSTRAINT fk_state_footnote
+        FOREIGN KEY(marker) 
+	    REFERENCES state_footnotes(marker)
+        ON DELETE CASCADE,
+
+    marker VARCHAR( 3 ) NOT NULL,
+    CONSTRAINT ux_state_footnote_cells UNIQUE (state_id, column_name, marker),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
@@ -0,0 +1,12 @@
+CREATE TABLE state_footnotes (
+    -- the letter marking the footnote in the state sheet, i.e "a" or "gg"
+    marker VARCHAR( 3 ) PRIMARY KEY,
+    footnote TEXT NOT NULL,
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
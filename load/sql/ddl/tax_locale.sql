@@ -1,13 +1,26 @@
 CREATE TABLE tax_locale (
+    -- id is derived from the natural key so it is stable across reloads
     tax_locale_id INTEGER PRIMARY KEY,
+    -- natural key of the jurisdiction, its normalized state and name
+    tax_locale_key VARCHAR( 100 ) NOT NULL,
     tax_locale VARCHAR( 50 ) NOT NULL,
-    -- the county id is a foriegn key for the tax jurisdiction table
+    -- the state id is a foriegn key for the state table, taken from the source sheet
+    CONSTRAINT fk_state
+        FOREIGN KEY(state_id) 
+	    REFERENCES states(state_id)
+        ON DELETE CASCADE,
+
+    state_id SMALLINT,
+    -- the county id is a foriegn key for the tax jurisdiction table. Null if the
+    -- jurisdiction could not be matched to a county.
     CONSTRAINT fk_county
         FOREIGN KEY(county_id) 
 	    REFERENCES county(county_id)
         ON DELETE CASCADE,
 
-    county_id INTEGER NOT NULL,
+    county_id INTEGER,
+    -- type of government levying the tax, i.e city, county or school_district
+    jurisdiction_type VARCHAR( 30 ) NOT NULL,
     -- all metrics are not null. Use zero value in load if not applicable.
     -- resident fields
     resident_desc VARCHAR( 50 ) NOT NULL,
@@ -15,12 +28,31 @@ CREATE TABLE tax_locale (
     resident_month_fee  DECIMAL NOT NULL,
     resident_year_fee  DECIMAL NOT NULL,
     resident_pay_period_fee  DECIMAL NOT NULL,
+    -- monthly, yearly and pay period fees over a year, at the configured pay frequency
+    resident_annual_flat_fee DECIMAL NOT NULL,
     resident_state_rate DECIMAL NOT NULL,
+    -- least and greatest rate, equal for a single rate
+    resident_min_rate DECIMAL NOT NULL,
+    resident_max_rate DECIMAL NOT NULL,
+    -- conditions on the rates or fees, i.e only applying to dividends. Null if unconditional.
+    resident_rate_note VARCHAR( 100 ),
     -- non-resident fields
     nonresident_desc VARCHAR( 50 ) NOT NULL,
     nonresident_rate DECIMAL NOT NULL,
     nonresident_month_fee DECIMAL NOT NULL,
     nonresident_year_fee DECIMAL NOT NULL,
     nonresident_pay_period_fee DECIMAL NOT NULL,
-    nonresident_state_rate DECIMAL NOT NULL
-);
+    nonresident_annual_flat_fee DECIMAL NOT NULL,
+    nonresident_state_rate DECIMAL NOT NULL,
+    nonresident_min_rate DECIMAL NOT NULL,
+    nonresident_max_rate DECIMAL NOT NULL,
+    nonresident_rate_note VARCHAR( 100 ),
+    CONSTRAINT ux_tax_locale_key UNIQUE (tax_locale_key),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
@@ -0,0 +1,26 @@
+CREATE TABLE tax_locale_county (
+    -- the tax locale id is a foriegn key for the tax jurisdiction table
+    CONSTRAINT fk_tax_locale
+        FOREIGN KEY(tax_locale_id) 
+	    REFERENCES tax_locale(tax_locale_id)
+        ON DELETE CASCADE,
+
+    tax_locale_id INTEGER NOT NULL,
+    -- the county id is a foriegn key for the county table
+    CONSTRAINT fk_county
+        FOREIGN KEY(county_id) 
+	    REFERENCES county(county_id)
+        ON DELETE CASCADE,
+
+    county_id INTEGER NOT NULL,
+    -- share of the jurisdiction's population living in the county. Null if unknown.
+    population_share DECIMAL(5, 4),
+    CONSTRAINT ux_tax_locale_county UNIQUE (tax_locale_id, county_id),
+    -- the source manifest entry the row was loaded from
+    CONSTRAINT fk_manifest
+        FOREIGN KEY(manifest_id) 
+	    REFERENCES source_manifest(manifest_id)
+        ON DELETE SET NULL,
+
+    manifest_id INTEGER
+);
\ No newline at end of file
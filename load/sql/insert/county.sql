@@ -8,7 +8,8 @@ INSERT INTO county
     female_pop,
     median_income,
     average_rent,
-    commute
+    commute,
+    manifest_id
     ) 
 -- initial row of values for the default county record
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?), 
\ No newline at end of file
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?), 
\ No newline at end of file
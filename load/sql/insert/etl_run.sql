@@ -0,0 +1,7 @@
+INSERT INTO etl_run(
+    run_id,
+    stage,
+    source_checksum,
+    status
+    ) 
+VALUES 
\ No newline at end of file
@@ -0,0 +1,8 @@
+INSERT INTO federal_additional_deductions
+    (
+    tax_year,
+    filing_status_id,
+    deduction,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
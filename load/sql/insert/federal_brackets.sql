@@ -1,8 +1,11 @@
 INSERT INTO federal_brackets
     (
+    tax_year,
+    filing_status_id,
+    ordinal,
     rate, 
-    single_bracket,
-    married_bracket,
-    head_bracket
+    lower_bound,
+    upper_bound,
+    manifest_id
     ) 
 VALUES 
\ No newline at end of file
@@ -0,0 +1,11 @@
+INSERT INTO federal_capital_gains_brackets
+    (
+    tax_year,
+    filing_status_id,
+    ordinal,
+    rate,
+    lower_bound,
+    upper_bound,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
@@ -0,0 +1,12 @@
+INSERT INTO federal_child_tax_credit
+    (
+    tax_year,
+    filing_status_id,
+    credit_per_child,
+    refundable_per_child,
+    other_dependent_credit,
+    phaseout_threshold,
+    phaseout_rate,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
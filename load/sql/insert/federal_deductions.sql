@@ -1,6 +1,7 @@
 INSERT INTO federal_deductions (
-    single_deduction, 
-    married_deduction,
-    head_deduction
+    tax_year,
+    filing_status_id, 
+    deduction,
+    manifest_id
     ) 
 VALUES 
\ No newline at end of file
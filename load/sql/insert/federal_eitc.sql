@@ -0,0 +1,12 @@
+INSERT INTO federal_eitc
+    (
+    tax_year,
+    filing_status_id,
+    children,
+    income_at_max_credit,
+    max_credit,
+    phaseout_begins,
+    phaseout_ends,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
@@ -0,0 +1,12 @@
+INSERT INTO federal_payroll_taxes
+    (
+    tax_year,
+    filing_status_id,
+    payroll_tax,
+    employee_rate,
+    employer_rate,
+    wage_base,
+    threshold,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
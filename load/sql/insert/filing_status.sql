@@ -0,0 +1,5 @@
+INSERT INTO filing_status (
+    filing_status_id,
+    filing_status
+    ) 
+VALUES 
\ No newline at end of file
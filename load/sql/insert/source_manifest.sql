@@ -0,0 +1,11 @@
+INSERT INTO source_manifest(
+    run_id,
+    source_type,
+    source_url,
+    source_path,
+    published_year,
+    tax_year,
+    checksum,
+    license
+    ) 
+VALUES 
\ No newline at end of file
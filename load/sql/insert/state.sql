@@ -1,11 +1,13 @@
 INSERT INTO states(
     state_id, 
     state_name,
-    single_deduction,
-    married_deduction,
-    single_exemption,
-    married_exemption,
-    dependent_exemption
+    abbreviation,
+    dependent_exemption,
+    dependent_exemption_credit,
+    no_income_tax,
+    interest_dividends_only,
+    capital_gains_only,
+    manifest_id
     ) 
 -- initial row of values for the default state record
-VALUES (?, ?, ?, ?, ?, ?, ?), 
\ No newline at end of file
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?), 
\ No newline at end of file
@@ -1,8 +1,11 @@
 INSERT INTO state_brackets(
     state_id, 
-    single_rate,
-    single_bracket,
-    married_rate,
-    married_bracket
+    filing_status_id,
+    ordinal,
+    rate,
+    lower_bound,
+    upper_bound,
+    fallback_rule,
+    manifest_id
     ) 
 VALUES
\ No newline at end of file
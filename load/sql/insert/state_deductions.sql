@@ -0,0 +1,11 @@
+INSERT INTO state_deductions(
+    state_id, 
+    filing_status_id,
+    deduction,
+    exemption,
+    deduction_credit,
+    exemption_credit,
+    fallback_rule,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
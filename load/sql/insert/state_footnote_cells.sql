@@ -0,0 +1,7 @@
+INSERT INTO state_footnote_cells(
+    state_id, 
+    column_name,
+    marker,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
@@ -0,0 +1,6 @@
+INSERT INTO state_footnotes(
+    marker, 
+    footnote,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
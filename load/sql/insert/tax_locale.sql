@@ -1,18 +1,30 @@
 INSERT INTO tax_locale(
     tax_locale_id,
+    tax_locale_key,
     tax_locale, 
+    state_id,
     county_id,
+    jurisdiction_type,
     resident_desc,
     resident_rate,
     resident_month_fee,
     resident_year_fee,
     resident_pay_period_fee,
+    resident_annual_flat_fee,
     resident_state_rate,
+    resident_min_rate,
+    resident_max_rate,
+    resident_rate_note,
     nonresident_desc,
     nonresident_rate,
     nonresident_month_fee,
     nonresident_year_fee,
     nonresident_pay_period_fee,
-    nonresident_state_rate
+    nonresident_annual_flat_fee,
+    nonresident_state_rate,
+    nonresident_min_rate,
+    nonresident_max_rate,
+    nonresident_rate_note,
+    manifest_id
     ) 
 VALUES 
\ No newline at end of file
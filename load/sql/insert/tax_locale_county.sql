@@ -0,0 +1,7 @@
+INSERT INTO tax_locale_county(
+    tax_locale_id,
+    county_id,
+    population_share,
+    manifest_id
+    ) 
+VALUES 
\ No newline at end of file
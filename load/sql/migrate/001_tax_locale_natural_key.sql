@@ -0,0 +1,23 @@
+-- tax locales are keyed by their normalized state and name. Existing rows keep their ids and take the key of the
+-- state of their county and their name without footnote markers. Rows on the null county record have no known
+-- state, so they are dropped to be reloaded by the next run of stage 4, as are all but the first row of a key.
+DO $$
+BEGIN
+    IF to_regclass('tax_locale') IS NOT NULL
+        AND NOT EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'tax_locale' AND column_name = 'tax_locale_key') THEN
+        ALTER TABLE tax_locale ADD COLUMN tax_locale_key VARCHAR( 100 );
+
+        UPDATE tax_locale SET tax_locale_key =
+            lower(regexp_replace(btrim(states.state_name), '\s+', ' ', 'g')) || ':' ||
+            lower(regexp_replace(btrim(regexp_replace(tax_locale.tax_locale, '\s*\([a-z]{1,2}\)\s*$', '')), '\s+', ' ', 'g'))
+        FROM county JOIN states ON county.state_id = states.state_id
+        WHERE tax_locale.county_id = county.county_id AND tax_locale.county_id <> 32767;
+
+        DELETE FROM tax_locale WHERE tax_locale_key IS NULL;
+        DELETE FROM tax_locale dup USING tax_locale kept
+        WHERE dup.tax_locale_key = kept.tax_locale_key AND dup.tax_locale_id > kept.tax_locale_id;
+
+        ALTER TABLE tax_locale ALTER COLUMN tax_locale_key SET NOT NULL;
+        ALTER TABLE tax_locale ADD CONSTRAINT ux_tax_locale_key UNIQUE (tax_locale_key);
+    END IF;
+END $$;
@@ -0,0 +1,14 @@
+-- tax locales carry their state, and jurisdictions without a matched county
+-- have a null county rather than the null county record
+DO $$
+BEGIN
+    IF to_regclass('tax_locale') IS NOT NULL THEN
+        ALTER TABLE tax_locale ADD COLUMN IF NOT EXISTS state_id SMALLINT
+            CONSTRAINT fk_state REFERENCES states(state_id) ON DELETE CASCADE;
+        ALTER TABLE tax_locale ALTER COLUMN county_id DROP NOT NULL;
+
+        UPDATE tax_locale SET county_id = NULL WHERE county_id = 32767;
+        UPDATE tax_locale SET state_id = county.state_id
+            FROM county WHERE tax_locale.county_id = county.county_id;
+    END IF;
+END $$;
@@ -0,0 +1,8 @@
+-- rate ranges and conditional notes of local taxes
+ALTER TABLE IF EXISTS tax_locale
+    ADD COLUMN IF NOT EXISTS resident_min_rate DECIMAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS resident_max_rate DECIMAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS resident_rate_note VARCHAR( 100 ),
+    ADD COLUMN IF NOT EXISTS nonresident_min_rate DECIMAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS nonresident_max_rate DECIMAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS nonresident_rate_note VARCHAR( 100 );
@@ -0,0 +1,3 @@
+-- type of government levying a local tax. Existing rows are typed on the next run of stage 4.
+ALTER TABLE IF EXISTS tax_locale
+    ADD COLUMN IF NOT EXISTS jurisdiction_type VARCHAR( 30 ) NOT NULL DEFAULT 'city';
@@ -0,0 +1,4 @@
+-- yearly total of the flat fees of a local tax. Existing rows are annualized on the next run of stage 4.
+ALTER TABLE IF EXISTS tax_locale
+    ADD COLUMN IF NOT EXISTS resident_annual_flat_fee DECIMAL NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS nonresident_annual_flat_fee DECIMAL NOT NULL DEFAULT 0;
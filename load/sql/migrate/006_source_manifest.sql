@@ -0,0 +1,24 @@
+-- source manifest referenced by every loaded table. Created here so tables created by earlier versions can
+-- reference it; the DDL is the same as ddl/source_manifest.sql.
+CREATE TABLE IF NOT EXISTS source_manifest (
+    manifest_id SERIAL PRIMARY KEY,
+    run_id VARCHAR( 40 ) NOT NULL,
+    source_type VARCHAR( 20 ) NOT NULL,
+    source_url VARCHAR( 300 ) NOT NULL,
+    source_path VARCHAR( 300 ) NOT NULL,
+    published_year SMALLINT NOT NULL,
+    tax_year SMALLINT NOT NULL,
+    checksum CHAR( 64 ) NOT NULL,
+    license TEXT NOT NULL,
+    loaded_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT ux_source_manifest UNIQUE (run_id, source_type)
+);
+
+-- existing rows have no manifest entry until they are reloaded
+ALTER TABLE IF EXISTS federal_brackets ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS federal_deductions ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS states ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS state_brackets ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS county ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS tax_locale ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
+ALTER TABLE IF EXISTS tax_locale_county ADD COLUMN IF NOT EXISTS manifest_id INTEGER REFERENCES source_manifest(manifest_id) ON DELETE SET NULL;
@@ -0,0 +1,146 @@
+-- filing statuses the bracket and deduction tables are keyed by. Created here so the tables converted below can
+-- reference it; the DDL is the same as ddl/filing_status.sql and the rows are reloaded by each federal and state load.
+CREATE TABLE IF NOT EXISTS filing_status (
+    filing_status_id SMALLINT PRIMARY KEY,
+    filing_status VARCHAR( 40 ) NOT NULL
+);
+
+INSERT INTO filing_status (filing_status_id, filing_status) VALUES
+    (1, 'single'),
+    (2, 'married filing jointly'),
+    (3, 'head of household'),
+    (4, 'married filing separately')
+ON CONFLICT (filing_status_id) DO NOTHING;
+
+-- convert the tables with a column per filing status to a row per filing status. Each table is rebuilt with the
+-- DDL of its ddl file, as the unique constraints change, and tables not created yet are left to their first load.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'federal_brackets' AND column_name = 'single_bracket') THEN
+        CREATE TABLE federal_brackets_long (
+            CONSTRAINT fk_filing_status
+                FOREIGN KEY(filing_status_id)
+                REFERENCES filing_status(filing_status_id)
+                ON DELETE CASCADE,
+
+            filing_status_id SMALLINT NOT NULL,
+            rate DECIMAL(3, 2) NOT NULL,
+            bracket INTEGER NOT NULL,
+            CONSTRAINT ux_federal_brackets UNIQUE (filing_status_id, bracket),
+            CONSTRAINT fk_manifest
+                FOREIGN KEY(manifest_id)
+                REFERENCES source_manifest(manifest_id)
+                ON DELETE SET NULL,
+
+            manifest_id INTEGER
+        );
+
+        INSERT INTO federal_brackets_long (filing_status_id, rate, bracket, manifest_id)
+            SELECT 1, rate, single_bracket, manifest_id FROM federal_brackets
+            UNION ALL SELECT 2, rate, married_bracket, manifest_id FROM federal_brackets
+            UNION ALL SELECT 3, rate, head_bracket, manifest_id FROM federal_brackets
+        ON CONFLICT DO NOTHING;
+
+        DROP TABLE federal_brackets;
+        ALTER TABLE federal_brackets_long RENAME TO federal_brackets;
+    END IF;
+
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'federal_deductions' AND column_name = 'single_deduction') THEN
+        CREATE TABLE federal_deductions_long (
+            CONSTRAINT fk_filing_status
+                FOREIGN KEY(filing_status_id)
+                REFERENCES filing_status(filing_status_id)
+                ON DELETE CASCADE,
+
+            filing_status_id SMALLINT NOT NULL,
+            deduction SMALLINT NOT NULL,
+            CONSTRAINT ux_federal_deductions UNIQUE (filing_status_id),
+            CONSTRAINT fk_manifest
+                FOREIGN KEY(manifest_id)
+                REFERENCES source_manifest(manifest_id)
+                ON DELETE SET NULL,
+
+            manifest_id INTEGER
+        );
+
+        INSERT INTO federal_deductions_long (filing_status_id, deduction, manifest_id)
+            SELECT 1, single_deduction, manifest_id FROM federal_deductions
+            UNION ALL SELECT 2, married_deduction, manifest_id FROM federal_deductions
+            UNION ALL SELECT 3, head_deduction, manifest_id FROM federal_deductions
+        ON CONFLICT DO NOTHING;
+
+        DROP TABLE federal_deductions;
+        ALTER TABLE federal_deductions_long RENAME TO federal_deductions;
+    END IF;
+
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'state_brackets' AND column_name = 'single_bracket') THEN
+        CREATE TABLE state_brackets_long (
+            CONSTRAINT fk_state
+                FOREIGN KEY(state_id)
+                REFERENCES states(state_id)
+                ON DELETE CASCADE,
+
+            state_id SMALLINT NOT NULL,
+            CONSTRAINT fk_filing_status
+                FOREIGN KEY(filing_status_id)
+                REFERENCES filing_status(filing_status_id)
+                ON DELETE CASCADE,
+
+            filing_status_id SMALLINT NOT NULL,
+            rate DECIMAL NOT NULL,
+            bracket INTEGER NOT NULL,
+            CONSTRAINT ux_state_filing_brackets UNIQUE (state_id, filing_status_id, bracket),
+            CONSTRAINT fk_manifest
+                FOREIGN KEY(manifest_id)
+                REFERENCES source_manifest(manifest_id)
+                ON DELETE SET NULL,
+
+            manifest_id INTEGER
+        );
+
+        INSERT INTO state_brackets_long (state_id, filing_status_id, rate, bracket, manifest_id)
+            SELECT state_id, 1, single_rate, single_bracket, manifest_id FROM state_brackets
+            UNION ALL SELECT state_id, 2, married_rate, married_bracket, manifest_id FROM state_brackets
+        ON CONFLICT DO NOTHING;
+
+        DROP TABLE state_brackets;
+        ALTER TABLE state_brackets_long RENAME TO state_brackets;
+    END IF;
+
+    -- the deductions and exemptions of each filing status move from the states table to the state deductions table
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'states' AND column_name = 'single_deduction') THEN
+        CREATE TABLE state_deductions (
+            CONSTRAINT fk_state
+                FOREIGN KEY(state_id)
+                REFERENCES states(state_id)
+                ON DELETE CASCADE,
+
+            state_id SMALLINT NOT NULL,
+            CONSTRAINT fk_filing_status
+                FOREIGN KEY(filing_status_id)
+                REFERENCES filing_status(filing_status_id)
+                ON DELETE CASCADE,
+
+            filing_status_id SMALLINT NOT NULL,
+            deduction INTEGER NOT NULL,
+            exemption SMALLINT NOT NULL,
+            CONSTRAINT ux_state_deductions UNIQUE (state_id, filing_status_id),
+            CONSTRAINT fk_manifest
+                FOREIGN KEY(manifest_id)
+                REFERENCES source_manifest(manifest_id)
+                ON DELETE SET NULL,
+
+            manifest_id INTEGER
+        );
+
+        INSERT INTO state_deductions (state_id, filing_status_id, deduction, exemption, manifest_id)
+            SELECT state_id, 1, single_deduction, single_exemption, manifest_id FROM states WHERE state_id != 32767
+            UNION ALL SELECT state_id, 2, married_deduction, married_exemption, manifest_id FROM states WHERE state_id != 32767;
+
+        ALTER TABLE states
+            DROP COLUMN single_deduction,
+            DROP COLUMN married_deduction,
+            DROP COLUMN single_exemption,
+            DROP COLUMN married_exemption;
+    END IF;
+END $$;
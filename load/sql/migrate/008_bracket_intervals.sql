@@ -0,0 +1,48 @@
+-- the threshold of each bracket becomes its lower bound, alongside its upper bound and its position among the
+-- brackets of its filing status. Existing rows are numbered by lower bound and bounded by the next bracket.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'federal_brackets' AND column_name = 'bracket') THEN
+        ALTER TABLE federal_brackets RENAME COLUMN bracket TO lower_bound;
+        ALTER TABLE federal_brackets ADD COLUMN ordinal SMALLINT, ADD COLUMN upper_bound INTEGER;
+
+        UPDATE federal_brackets SET ordinal = intervals.ordinal, upper_bound = intervals.upper_bound
+        FROM (
+            SELECT 
+                filing_status_id,
+                lower_bound,
+                ROW_NUMBER() OVER (PARTITION BY filing_status_id ORDER BY lower_bound) AS ordinal,
+                LEAD(lower_bound) OVER (PARTITION BY filing_status_id ORDER BY lower_bound) AS upper_bound
+            FROM federal_brackets
+        ) AS intervals
+        WHERE federal_brackets.filing_status_id = intervals.filing_status_id 
+            AND federal_brackets.lower_bound = intervals.lower_bound;
+
+        ALTER TABLE federal_brackets ALTER COLUMN ordinal SET NOT NULL;
+        ALTER TABLE federal_brackets DROP CONSTRAINT ux_federal_brackets;
+        ALTER TABLE federal_brackets ADD CONSTRAINT ux_federal_brackets UNIQUE (filing_status_id, ordinal);
+    END IF;
+
+    IF EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'state_brackets' AND column_name = 'bracket') THEN
+        ALTER TABLE state_brackets RENAME COLUMN bracket TO lower_bound;
+        ALTER TABLE state_brackets ADD COLUMN ordinal SMALLINT, ADD COLUMN upper_bound INTEGER;
+
+        UPDATE state_brackets SET ordinal = intervals.ordinal, upper_bound = intervals.upper_bound
+        FROM (
+            SELECT 
+                state_id,
+                filing_status_id,
+                lower_bound,
+                ROW_NUMBER() OVER (PARTITION BY state_id, filing_status_id ORDER BY lower_bound) AS ordinal,
+                LEAD(lower_bound) OVER (PARTITION BY state_id, filing_status_id ORDER BY lower_bound) AS upper_bound
+            FROM state_brackets
+        ) AS intervals
+        WHERE state_brackets.state_id = intervals.state_id 
+            AND state_brackets.filing_status_id = intervals.filing_status_id
+            AND state_brackets.lower_bound = intervals.lower_bound;
+
+        ALTER TABLE state_brackets ALTER COLUMN ordinal SET NOT NULL;
+        ALTER TABLE state_brackets DROP CONSTRAINT ux_state_filing_brackets;
+        ALTER TABLE state_brackets ADD CONSTRAINT ux_state_filing_brackets UNIQUE (state_id, filing_status_id, ordinal);
+    END IF;
+END $$;
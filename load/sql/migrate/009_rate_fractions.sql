@@ -0,0 +1,18 @@
+-- rates of every table are fractions. Local tax rates and shares of state liability were stored as percentages.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'tax_locale') THEN
+        UPDATE tax_locale SET
+            resident_rate = resident_rate / 100,
+            resident_state_rate = resident_state_rate / 100,
+            resident_min_rate = resident_min_rate / 100,
+            resident_max_rate = resident_max_rate / 100,
+            nonresident_rate = nonresident_rate / 100,
+            nonresident_state_rate = nonresident_state_rate / 100,
+            nonresident_min_rate = nonresident_min_rate / 100,
+            nonresident_max_rate = nonresident_max_rate / 100;
+    END IF;
+END $$;
+
+-- federal rates with more than 2 decimal places, such as 0.095, no longer round
+ALTER TABLE IF EXISTS federal_brackets ALTER COLUMN rate TYPE DECIMAL;
@@ -0,0 +1,18 @@
+-- flags of the special cases the state sheet gives as text. The footnote tables are created by their first load.
+ALTER TABLE IF EXISTS states
+    ADD COLUMN IF NOT EXISTS dependent_exemption_credit BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS no_income_tax BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS interest_dividends_only BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS capital_gains_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE IF EXISTS state_deductions
+    ADD COLUMN IF NOT EXISTS deduction_credit BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS exemption_credit BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- the flags and footnotes are only read by a run of the state stage, so its last run no longer skips it
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'etl_run') THEN
+        DELETE FROM etl_run WHERE stage = '2';
+    END IF;
+END $$;
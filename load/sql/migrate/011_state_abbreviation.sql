@@ -0,0 +1,10 @@
+-- the USPS code of each state, from the bundled state reference
+ALTER TABLE IF EXISTS states ADD COLUMN IF NOT EXISTS abbreviation CHAR ( 2 );
+
+-- the abbreviations are only filled by a run of the state stage, so its last run no longer skips it
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'etl_run') THEN
+        DELETE FROM etl_run WHERE stage = '2';
+    END IF;
+END $$;
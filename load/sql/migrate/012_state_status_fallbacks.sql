@@ -0,0 +1,11 @@
+-- the rule head of household and married filing separately rows are derived by when a state does not publish them
+ALTER TABLE IF EXISTS state_brackets ADD COLUMN IF NOT EXISTS fallback_rule VARCHAR( 40 );
+ALTER TABLE IF EXISTS state_deductions ADD COLUMN IF NOT EXISTS fallback_rule VARCHAR( 40 );
+
+-- the derived rows are only added by a run of the state stage, so its last run no longer skips it
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'etl_run') THEN
+        DELETE FROM etl_run WHERE stage = '2';
+    END IF;
+END $$;
@@ -0,0 +1,28 @@
+-- amounts above 32,767, such as the married filing jointly standard deduction, overflowed SMALLINT
+ALTER TABLE IF EXISTS federal_deductions ALTER COLUMN deduction TYPE INTEGER;
+ALTER TABLE IF EXISTS states ALTER COLUMN dependent_exemption TYPE INTEGER;
+ALTER TABLE IF EXISTS state_deductions ALTER COLUMN exemption TYPE INTEGER;
+
+-- federal deductions are keyed by tax year. Existing rows take the tax year of the source they were loaded from, and
+-- rows without a source are dropped to be reloaded by the next run of the federal stage.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'federal_deductions')
+        AND NOT EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'federal_deductions' AND column_name = 'tax_year') THEN
+        ALTER TABLE federal_deductions ADD COLUMN tax_year SMALLINT;
+
+        UPDATE federal_deductions SET tax_year = source_manifest.tax_year
+        FROM source_manifest
+        WHERE federal_deductions.manifest_id = source_manifest.manifest_id;
+
+        DELETE FROM federal_deductions WHERE tax_year IS NULL;
+
+        ALTER TABLE federal_deductions ALTER COLUMN tax_year SET NOT NULL;
+        ALTER TABLE federal_deductions DROP CONSTRAINT ux_federal_deductions;
+        ALTER TABLE federal_deductions ADD CONSTRAINT ux_federal_deductions UNIQUE (tax_year, filing_status_id);
+    END IF;
+
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'etl_run') THEN
+        DELETE FROM etl_run WHERE stage = '1';
+    END IF;
+END $$;
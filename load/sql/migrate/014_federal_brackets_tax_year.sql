@@ -0,0 +1,23 @@
+-- federal brackets are keyed by tax year. Existing rows take the tax year of the source they were loaded from, and
+-- rows without a source are dropped to be reloaded by the next run of the federal stage.
+DO $$
+BEGIN
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'federal_brackets')
+        AND NOT EXISTS (SELECT FROM information_schema.columns WHERE table_schema = 'public' AND table_name = 'federal_brackets' AND column_name = 'tax_year') THEN
+        ALTER TABLE federal_brackets ADD COLUMN tax_year SMALLINT;
+
+        UPDATE federal_brackets SET tax_year = source_manifest.tax_year
+        FROM source_manifest
+        WHERE federal_brackets.manifest_id = source_manifest.manifest_id;
+
+        DELETE FROM federal_brackets WHERE tax_year IS NULL;
+
+        ALTER TABLE federal_brackets ALTER COLUMN tax_year SET NOT NULL;
+        ALTER TABLE federal_brackets DROP CONSTRAINT ux_federal_brackets;
+        ALTER TABLE federal_brackets ADD CONSTRAINT ux_federal_brackets UNIQUE (tax_year, filing_status_id, ordinal);
+    END IF;
+
+    IF EXISTS (SELECT FROM pg_tables WHERE schemaname = 'public' AND tablename = 'etl_run') THEN
+        DELETE FROM etl_run WHERE stage = '1';
+    END IF;
+END $$;
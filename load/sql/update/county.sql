@@ -5,5 +5,5 @@ ON CONFLICT (county_id) DO UPDATE SET
     female_pop = EXCLUDED.female_pop,
     median_income = EXCLUDED.median_income,
     average_rent = EXCLUDED.average_rent,
-    commute = EXCLUDED.commute;
-    
\ No newline at end of file
+    commute = EXCLUDED.commute,
+    manifest_id = EXCLUDED.manifest_id;
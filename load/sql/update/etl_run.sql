@@ -0,0 +1,6 @@
+-- a stage run again by the same run starts over
+ON CONFLICT (run_id, stage) DO UPDATE SET
+    source_checksum = EXCLUDED.source_checksum,
+    status = EXCLUDED.status,
+    started_at = NOW(),
+    finished_at = NULL;
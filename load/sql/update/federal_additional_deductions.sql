@@ -0,0 +1,4 @@
+-- update the additional deduction if it changes for a filing status and year
+ON CONFLICT (tax_year, filing_status_id) DO UPDATE SET 
+    deduction = EXCLUDED.deduction,
+    manifest_id = EXCLUDED.manifest_id;
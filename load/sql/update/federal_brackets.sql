@@ -1,6 +1,6 @@
--- update rates if they change for a set of brackets
-ON CONFLICT (single_bracket, married_bracket, head_bracket) DO UPDATE SET 
+-- update rates and bounds if they change for a bracket of a filing status and year
+ON CONFLICT (tax_year, filing_status_id, ordinal) DO UPDATE SET 
     rate = EXCLUDED.rate,
-    single_bracket = EXCLUDED.single_bracket,
-    married_bracket = EXCLUDED.married_bracket,
-    head_bracket = EXCLUDED.head_bracket;
+    lower_bound = EXCLUDED.lower_bound,
+    upper_bound = EXCLUDED.upper_bound,
+    manifest_id = EXCLUDED.manifest_id;
@@ -0,0 +1,6 @@
+-- update rates and bounds if they change for a capital gains bracket of a filing status and year
+ON CONFLICT (tax_year, filing_status_id, ordinal) DO UPDATE SET 
+    rate = EXCLUDED.rate,
+    lower_bound = EXCLUDED.lower_bound,
+    upper_bound = EXCLUDED.upper_bound,
+    manifest_id = EXCLUDED.manifest_id;
@@ -0,0 +1,8 @@
+-- update the child tax credit if it changes for a filing status and year
+ON CONFLICT (tax_year, filing_status_id) DO UPDATE SET 
+    credit_per_child = EXCLUDED.credit_per_child,
+    refundable_per_child = EXCLUDED.refundable_per_child,
+    other_dependent_credit = EXCLUDED.other_dependent_credit,
+    phaseout_threshold = EXCLUDED.phaseout_threshold,
+    phaseout_rate = EXCLUDED.phaseout_rate,
+    manifest_id = EXCLUDED.manifest_id;
@@ -1,4 +1,4 @@
-ON CONFLICT (single_deduction, married_deduction, head_deduction) DO UPDATE SET
-    single_deduction = EXCLUDED.single_deduction,
-    married_deduction = EXCLUDED.married_deduction,
-    head_deduction = EXCLUDED.head_deduction;
+-- update the deduction if it changes for a filing status and year
+ON CONFLICT (tax_year, filing_status_id) DO UPDATE SET
+    deduction = EXCLUDED.deduction,
+    manifest_id = EXCLUDED.manifest_id;
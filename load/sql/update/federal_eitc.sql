@@ -0,0 +1,7 @@
+-- update the earned income tax credit if it changes for a filing status, number of children and year
+ON CONFLICT (tax_year, filing_status_id, children) DO UPDATE SET 
+    income_at_max_credit = EXCLUDED.income_at_max_credit,
+    max_credit = EXCLUDED.max_credit,
+    phaseout_begins = EXCLUDED.phaseout_begins,
+    phaseout_ends = EXCLUDED.phaseout_ends,
+    manifest_id = EXCLUDED.manifest_id;
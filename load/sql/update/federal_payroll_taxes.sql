@@ -0,0 +1,7 @@
+-- update the rates, wage base and threshold if they change for a payroll tax of a filing status and year
+ON CONFLICT (tax_year, filing_status_id, payroll_tax) DO UPDATE SET 
+    employee_rate = EXCLUDED.employee_rate,
+    employer_rate = EXCLUDED.employer_rate,
+    wage_base = EXCLUDED.wage_base,
+    threshold = EXCLUDED.threshold,
+    manifest_id = EXCLUDED.manifest_id;
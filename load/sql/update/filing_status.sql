@@ -0,0 +1,2 @@
+ON CONFLICT (filing_status_id) DO UPDATE SET
+    filing_status = EXCLUDED.filing_status;
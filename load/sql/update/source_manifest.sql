@@ -0,0 +1,9 @@
+-- a source is recorded once per run, so a repeated stage refers to the same entry
+ON CONFLICT (run_id, source_type) DO UPDATE SET
+    source_url = EXCLUDED.source_url,
+    source_path = EXCLUDED.source_path,
+    published_year = EXCLUDED.published_year,
+    tax_year = EXCLUDED.tax_year,
+    checksum = EXCLUDED.checksum,
+    license = EXCLUDED.license
+RETURNING manifest_id;
@@ -1,7 +1,9 @@
 -- assume id and name are constant
 ON CONFLICT (state_id) DO UPDATE SET
-    single_deduction = EXCLUDED.single_deduction,
-    married_deduction = EXCLUDED.married_deduction,
-    single_exemption = EXCLUDED.single_exemption,
-    married_exemption = EXCLUDED.married_exemption,
-    dependent_exemption = EXCLUDED.dependent_exemption;
+    abbreviation = EXCLUDED.abbreviation,
+    dependent_exemption = EXCLUDED.dependent_exemption,
+    dependent_exemption_credit = EXCLUDED.dependent_exemption_credit,
+    no_income_tax = EXCLUDED.no_income_tax,
+    interest_dividends_only = EXCLUDED.interest_dividends_only,
+    capital_gains_only = EXCLUDED.capital_gains_only,
+    manifest_id = EXCLUDED.manifest_id;
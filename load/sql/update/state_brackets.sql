@@ -1,6 +1,7 @@
--- update rates if they change for a set of brackets for a given state
-ON CONFLICT (state_id, single_bracket, married_bracket) DO UPDATE SET
-    single_rate = EXCLUDED.single_rate,
-    single_bracket = EXCLUDED.single_bracket,
-    married_rate = EXCLUDED.married_rate,
-    married_bracket = EXCLUDED.married_bracket;
+-- update rates and bounds if they change for a bracket of a filing status of a given state
+ON CONFLICT (state_id, filing_status_id, ordinal) DO UPDATE SET
+    rate = EXCLUDED.rate,
+    lower_bound = EXCLUDED.lower_bound,
+    upper_bound = EXCLUDED.upper_bound,
+    fallback_rule = EXCLUDED.fallback_rule,
+    manifest_id = EXCLUDED.manifest_id;
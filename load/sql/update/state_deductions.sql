@@ -0,0 +1,7 @@
+ON CONFLICT (state_id, filing_status_id) DO UPDATE SET
+    deduction = EXCLUDED.deduction,
+    exemption = EXCLUDED.exemption,
+    deduction_credit = EXCLUDED.deduction_credit,
+    exemption_credit = EXCLUDED.exemption_credit,
+    fallback_rule = EXCLUDED.fallback_rule,
+    manifest_id = EXCLUDED.manifest_id;
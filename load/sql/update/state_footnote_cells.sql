@@ -0,0 +1,3 @@
+-- a marker of a cell is only recorded once
+ON CONFLICT (state_id, column_name, marker) DO UPDATE SET
+    manifest_id = EXCLUDED.manifest_id;
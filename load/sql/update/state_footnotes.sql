@@ -0,0 +1,4 @@
+-- update the text of a footnote if it changes
+ON CONFLICT (marker) DO UPDATE SET
+    footnote = EXCLUDED.footnote,
+    manifest_id = EXCLUDED.manifest_id;
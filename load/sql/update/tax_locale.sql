@@ -1,15 +1,28 @@
--- assume ids and names are static
-ON CONFLICT (tax_locale_id) DO UPDATE SET
+-- the natural key determines the id, so the id is kept as is
+ON CONFLICT (tax_locale_key) DO UPDATE SET
+    tax_locale = EXCLUDED.tax_locale,
+    state_id = EXCLUDED.state_id,
+    county_id = EXCLUDED.county_id,
+    jurisdiction_type = EXCLUDED.jurisdiction_type,
     resident_desc = EXCLUDED.resident_desc,
     resident_rate = EXCLUDED.resident_rate,
     resident_month_fee = EXCLUDED.resident_month_fee,
     resident_year_fee = EXCLUDED.resident_year_fee,
     resident_pay_period_fee = EXCLUDED.resident_pay_period_fee,
+    resident_annual_flat_fee = EXCLUDED.resident_annual_flat_fee,
     resident_state_rate = EXCLUDED.resident_state_rate,
+    resident_min_rate = EXCLUDED.resident_min_rate,
+    resident_max_rate = EXCLUDED.resident_max_rate,
+    resident_rate_note = EXCLUDED.resident_rate_note,
     -- non-resident fields
     nonresident_desc = EXCLUDED.nonresident_desc,
     nonresident_rate = EXCLUDED.nonresident_rate,
     nonresident_month_fee = EXCLUDED.nonresident_month_fee,
     nonresident_year_fee = EXCLUDED.nonresident_year_fee,
     nonresident_pay_period_fee = EXCLUDED.nonresident_pay_period_fee,
-    nonresident_state_rate = EXCLUDED.nonresident_state_rate;
+    nonresident_annual_flat_fee = EXCLUDED.nonresident_annual_flat_fee,
+    nonresident_state_rate = EXCLUDED.nonresident_state_rate,
+    nonresident_min_rate = EXCLUDED.nonresident_min_rate,
+    nonresident_max_rate = EXCLUDED.nonresident_max_rate,
+    nonresident_rate_note = EXCLUDED.nonresident_rate_note,
+    manifest_id = EXCLUDED.manifest_id;
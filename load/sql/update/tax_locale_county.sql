@@ -0,0 +1,4 @@
+-- update the share if it changes for a link
+ON CONFLICT (tax_locale_id, county_id) DO UPDATE SET
+    population_share = EXCLUDED.population_share,
+    manifest_id = EXCLUDED.manifest_id;
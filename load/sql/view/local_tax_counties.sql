@@ -0,0 +1,16 @@
+-- view exposing every county a local income tax applies to
+DROP VIEW IF EXISTS local_tax_counties;
+
+CREATE VIEW local_tax_counties AS
+    SELECT 
+        tax_locale.tax_locale_id,
+        tax_locale.tax_locale,
+        tax_locale.state_id,
+        county.county_id,
+        county.county_name,
+        tax_locale_county.population_share,
+        -- the primary county is the one stored on the tax locale
+        tax_locale.county_id = county.county_id AS is_primary
+    FROM tax_locale 
+        INNER JOIN tax_locale_county ON tax_locale.tax_locale_id = tax_locale_county.tax_locale_id
+        INNER JOIN county ON tax_locale_county.county_id = county.county_id;
@@ -7,18 +7,28 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/Matthew-Curry/re-region-etl/calculator"
 	"github.com/Matthew-Curry/re-region-etl/load"
 	"github.com/Matthew-Curry/re-region-etl/extract"
 	"github.com/Matthew-Curry/re-region-etl/logging"
+	sourcefileutils "github.com/Matthew-Curry/re-region-etl/sourceFileUtils"
 )
 
 // logger for the app
 var logger logging.Logger
 var logFile *os.File
 
+// stages loading tables that reference the tables of each stage, whose rows are deleted when the stage's tables are
+// cleared
+var dependentStages = map[string][]string{"2": {"3", "4"}, "3": {"4"}}
+
 func main() {
 	// the logger and file to close
 	logger, logFile = logging.GetLogger("file.log")
@@ -29,7 +39,26 @@ func main() {
 	yaml.Unmarshal(yfile, &configData)
 	censusAttempts := configData["census"]["attempts"]
 	matchThresh := configData["localTax"]["threshold"]
+	matchWorkers := configData["localTax"]["workers"]
+	payFrequency := configData["localTax"]["payFrequency"]
+	overridesFile := configData["localTax"]["overridesFile"]
+	placeCountyFile := configData["localTax"]["placeCountyFile"]
+	irsParametersFile := configData["federal"]["parametersFile"]
 	nullString := configData["general"]["nullString"]
+	sourceDir := configData["sources"]["dir"]
+	configYear := configData["sources"]["year"]
+	sourcesConfig := configData["sources"]["config"]
+	sourcesBaseUrl := configData["sources"]["baseUrl"]
+	sourcesCacheDir := configData["sources"]["cacheDir"]
+	activeSourcesFile := configData["sources"]["activeFile"]
+	fetchAttempts := configData["sources"]["attempts"]
+
+	// the fetch-sources command fetches the source workbooks into the cache, without the database
+	if len(os.Args) > 1 && os.Args[1] == "fetch-sources" {
+		fetchSources(os.Args[2:], sourcesConfig.(string), sourcesBaseUrl.(string), sourcesCacheDir.(string), activeSourcesFile.(string), fetchAttempts.(int))
+		return
+	}
+
 	// get the DB params from env vars
 	dbUser := os.Getenv("RE_REGION_ETL_USER")
 	dbPassword := os.Getenv("RE_REGION_ETL_PASSWORD")
@@ -40,6 +69,8 @@ func main() {
 	c := flag.Bool("c", false, "c, Clears existing tables resulting from the ETL stages to run. Will only take effect if l option is provided to trigger the ETL")
 	l := flag.Bool("l", false, "l, Runs the ETL code to load the tables")
 	v := flag.Bool("v", false, "v, Runs SQL to define the views.")
+	force := flag.Bool("force", false, "force, Runs the ETL stages even if their sources are unchanged since their last successful run. Implied by c")
+	year := flag.Int("year", configYear.(int), "year, Tax year of the source workbooks to load. 0 loads the latest year found.")
 	flag.Parse()
 	// remaining args define stages
 	var stages []string
@@ -53,9 +84,39 @@ func main() {
 		logger.Error("Unable to create the db engine. Recieved error: %s", err)
 	}
 
+	// the estimate command estimates the taxes of a household from the loaded tables
+	if len(os.Args) > 1 && os.Args[1] == "estimate" {
+		estimate(os.Args[2:], engine)
+		return
+	}
+
+	// bring existing tables up to date before loading or defining views
+	if *l == true || *v == true {
+		err = engine.Migrate()
+		if err != nil {
+			logger.Error("Unable to migrate the database. Recieved error: %s", err)
+		}
+	}
+
 	// run the ETL with the provided parameters if l option provided
 	if *l == true {
-		runETL(*c, stages, censusAttempts.(int), matchThresh.(int), nullString.(string), engine)
+		// find the Tax Foundation workbooks of each year in the source directory
+		workbooks, err := sourcefileutils.DiscoverWorkbooks(sourceDir.(string))
+		if err != nil {
+			logger.Error("Unable to discover the source workbooks. Recieved error: %s", err)
+		}
+
+		// fetched workbooks take the place of those in the source directory of the same type and year
+		activeWorkbooks, err := sourcefileutils.ReadActiveSources(activeSourcesFile.(string))
+		if err != nil {
+			logger.Error("Unable to read the active sources. Recieved error: %s", err)
+		}
+		workbooks = sourcefileutils.MergeActiveSources(workbooks, activeWorkbooks)
+
+		// id of this run in the source manifest
+		runId := time.Now().UTC().Format("20060102T150405.000000Z")
+
+		runETL(*c, *force, stages, runId, workbooks, *year, irsParametersFile.(string), censusAttempts.(int), matchThresh.(int), matchWorkers.(int), payFrequency.(string), overridesFile.(string), placeCountyFile.(string), nullString.(string), engine)
 	}
 
 	// refresh the views if the v option is provided
@@ -68,38 +129,131 @@ func main() {
 
 }
 
-func runETL(c bool, stages []string, censusAttempts int, matchThresh int, nullString string, engine *load.DbEngine) {
+func runETL(c bool, force bool, stages []string, runId string, workbooks []sourcefileutils.Workbook, taxYear int, irsParametersFile string, censusAttempts int, matchThresh int, matchWorkers int, payFrequency string, overridesFile string, placeCountyFile string, nullString string, engine *load.DbEngine) {
 	// initialized in memory data structures to load to tables
 	var censusData [][]string
 	var localTaxData [][]string
+	var localTaxCounties [][]string
 	var stateBrackets [][]string
-	var stateExemptions [][]string
+	var states [][]string
+	var stateDeductions [][]string
+	var stateFootnotes [][]string
+	var stateFootnoteCells [][]string
 	var federalBrackets [][]string
-	var federalDeductions []string
+	var federalDeductions [][]string
+	var federalCapitalGains [][]string
+	var federalEitc [][]string
+	var federalAdditionalDeductions [][]string
+	var federalChildTaxCredits [][]string
+	var federalPayrollTaxes [][]string
+	// the source workbook of the stage being run
+	var workbook sourcefileutils.Workbook
+	// checksum of the census response and the manifest entry of the sources of the stage being run
+	var censusChecksum string
+	var manifestId string
+	// checksum of the sources and settings of the stage being run
+	var stageChecksum string
+
+	// clearing tables reloads them, so no stage is skipped
+	force = force || c
 
 	var err error
 	// load data in order of descending geography. This is the order dictated by the required database dependencies.
 	// The first stage for the federal data is independent, but the next 3 are linked and will load the prior dependent
-	// stage if specified (i.e passing stage 4 will load 2, 3 and 4 out of necessity)
+	// stage if specified (i.e passing stage 4 will load 2, 3 and 4 out of necessity). A stage whose sources are
+	// unchanged since its last successful run is skipped unless forced.
 
 	if contains(stages, "1") {
 		logger.Info("RUNNING STAGE 1, LOAD TO FEDERAL TABLES")
-		// retrieve 2d array of federal tax data
-		federalBrackets, federalDeductions, err = extract.GetFederalTaxData()
-
+		// retrieve 2d array of federal tax data from the workbook of the tax year
+		workbook, err = sourcefileutils.SelectWorkbook(workbooks, sourcefileutils.FEDERAL_WORKBOOK, taxYear)
 		if err != nil {
 			logger.Error(getDataErrorStr("federal", err))
 		}
 
-		// load the 2 federal tables
-		err = engine.LoadFederalDeductionsTable(federalDeductions, c)
-		if err != nil {
-			logger.Error(getLoadErrorStr("federal", err))
-		}
-
-		err = engine.LoadFederalBracketTable(federalBrackets, c)
-		if err != nil {
-			logger.Error(getLoadErrorStr("federal bracket", err))
+		// the IRS parameters the workbook does not give come from the parameters file
+		irsChecksum := getOptionalFileChecksum(irsParametersFile)
+		stageChecksum = extract.GetStageChecksum(append(extract.GetWorkbookChecksumParts(workbook), irsChecksum)...)
+		if !skipStage(engine, "1", stageChecksum, force) {
+			startStage(engine, runId, "1", stageChecksum)
+
+			federalBrackets, federalDeductions, err = extract.GetFederalTaxData(workbook, nullString)
+
+			if err != nil {
+				logger.Error(getDataErrorStr("federal", err))
+			}
+
+			federalCapitalGains, federalEitc, err = extract.GetFederalParameterData(workbook, nullString)
+			if err != nil {
+				logger.Error(getDataErrorStr("federal parameter", err))
+			}
+
+			federalAdditionalDeductions, federalChildTaxCredits, federalPayrollTaxes, err = extract.GetIrsParameterData(irsParametersFile, workbook.Year, nullString)
+			if err != nil {
+				logger.Error(getDataErrorStr("IRS parameter", err))
+			}
+
+			// record the workbook the rows come from
+			manifestId, err = engine.LoadSourceManifest(extract.GetWorkbookManifest(runId, workbook))
+			if err != nil {
+				logger.Error(getLoadErrorStr("source manifest", err))
+			}
+
+			// load the filing statuses the federal tables are keyed by, then the federal tables
+			err = engine.LoadFilingStatusTable(extract.GetFilingStatusData())
+			if err != nil {
+				logger.Error(getLoadErrorStr("filing status", err))
+			}
+
+			err = engine.LoadFederalDeductionsTable(federalDeductions, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal", err))
+			}
+
+			err = engine.LoadFederalBracketTable(federalBrackets, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal bracket", err))
+			}
+
+			err = engine.LoadFederalCapitalGainsTable(federalCapitalGains, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal capital gains", err))
+			}
+
+			err = engine.LoadFederalEitcTable(federalEitc, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal earned income tax credit", err))
+			}
+
+			// the IRS parameters are recorded as a source of their own
+			if irsParametersFile != "" {
+				manifest, err := extract.GetIrsParametersManifest(runId, irsParametersFile, workbook.Year, irsChecksum)
+				if err != nil {
+					logger.Error(getDataErrorStr("IRS parameter", err))
+				}
+
+				manifestId, err = engine.LoadSourceManifest(manifest)
+				if err != nil {
+					logger.Error(getLoadErrorStr("source manifest", err))
+				}
+			}
+
+			err = engine.LoadFederalAdditionalDeductionTable(federalAdditionalDeductions, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal additional deduction", err))
+			}
+
+			err = engine.LoadFederalChildTaxCreditTable(federalChildTaxCredits, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal child tax credit", err))
+			}
+
+			err = engine.LoadFederalPayrollTaxTable(federalPayrollTaxes, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("federal payroll tax", err))
+			}
+
+			finishStage(engine, runId, "1")
 		}
 
 	}
@@ -107,55 +261,321 @@ func runETL(c bool, stages []string, censusAttempts int, matchThresh int, nullSt
 	// load if stage 2 is requested or any more granular geography
 	if contains(stages, "2") || contains(stages, "3") || contains(stages, "4") {
 		logger.Info("RUNNING STAGE 2, LOAD TO STATE TABLE")
-		// get census data at the county level as 2D array
-		censusData, err = extract.GetCensusData(censusAttempts)
+		// get the state data of the tax year as a 2d array
+		workbook, err = sourcefileutils.SelectWorkbook(workbooks, sourcefileutils.STATE_WORKBOOK, taxYear)
 		if err != nil {
-			logger.Error(getDataErrorStr("census", err))
-		}
-
-		// get the state data as a 2d array
-		stateBrackets, stateExemptions, err = extract.GetStateTaxData(censusData, nullString)
-
-		// use the state data to load the state tables in order of dependencies
-		err = engine.LoadStateTable(stateExemptions, c)
-		if err != nil {
-			logger.Error(getLoadErrorStr("state", err))
+			logger.Error(getDataErrorStr("state", err))
 		}
 
-		err = engine.LoadStateBracketTable(stateBrackets, c)
-		if err != nil {
-			logger.Error(getLoadErrorStr("state bracket", err))
+		// states are identified by the bundled state reference, so the stage needs no census data
+		stageChecksum = extract.GetStageChecksum(append(extract.GetWorkbookChecksumParts(workbook), nullString)...)
+		if !skipStage(engine, "2", stageChecksum, force) {
+			startStage(engine, runId, "2", stageChecksum)
+			resetDependentStages(engine, "2", c)
+
+			stateBrackets, states, stateDeductions, stateFootnotes, stateFootnoteCells, err = extract.GetStateTaxData(workbook, nullString)
+			if err != nil {
+				logger.Error(getDataErrorStr("state", err))
+			}
+
+			manifestId, err = engine.LoadSourceManifest(extract.GetWorkbookManifest(runId, workbook))
+			if err != nil {
+				logger.Error(getLoadErrorStr("source manifest", err))
+			}
+
+			// use the state data to load the state tables in order of dependencies
+			err = engine.LoadFilingStatusTable(extract.GetFilingStatusData())
+			if err != nil {
+				logger.Error(getLoadErrorStr("filing status", err))
+			}
+
+			err = engine.LoadStateTable(states, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("state", err))
+			}
+
+			err = engine.LoadStateFootnoteTable(stateFootnotes, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("state footnote", err))
+			}
+
+			err = engine.LoadStateFootnoteCellTable(stateFootnoteCells, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("state footnote cell", err))
+			}
+
+			err = engine.LoadStateBracketTable(stateBrackets, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("state bracket", err))
+			}
+
+			err = engine.LoadStateDeductionTable(stateDeductions, manifestId, c)
+			if err != nil {
+				logger.Error(getLoadErrorStr("state deduction", err))
+			}
+
+			finishStage(engine, runId, "2")
 		}
 	}
 
 	// load stage 3 if requested or any more granular geography
 	if contains(stages, "3") || contains(stages, "4") {
 		logger.Info("RUNNING STAGE 3, LOAD TO COUNTY TABLE")
-		// state table is loaded, so census data can now be used to load the county table
-		err = engine.LoadCountyTable(censusData, c)
-
+		// get census data at the county level as 2D array. It is needed by the later stages even if this one is skipped.
+		censusData, censusChecksum, err = extract.GetCensusData(censusAttempts)
 		if err != nil {
-			logger.Error(getLoadErrorStr("county", err))
+			logger.Error(getDataErrorStr("census", err))
+		}
+
+		stageChecksum = extract.GetStageChecksum(censusChecksum, nullString)
+		if !skipStage(engine, "3", stageChecksum, force) {
+			startStage(engine, runId, "3", stageChecksum)
+			resetDependentStages(engine, "3", c)
+
+			// the county rows come from the census response
+			manifestId, err = engine.LoadSourceManifest(extract.GetCensusManifest(runId, censusChecksum))
+			if err != nil {
+				logger.Error(getLoadErrorStr("source manifest", err))
+			}
+
+			// state table is loaded, so census data can now be used to load the county table
+			err = engine.LoadCountyTable(censusData, manifestId, c)
+
+			if err != nil {
+				logger.Error(getLoadErrorStr("county", err))
+			}
+
+			finishStage(engine, runId, "3")
 		}
 	}
 
 	if contains(stages, "4") {
 		logger.Info("RUNNING STAGE 4, LOAD TO LOCAL TAX JURISDICTION TABLE")
-		// retrieve 2d array of state tax data
-		localTaxData, err = extract.GetLocalTaxData(censusData, matchThresh, nullString)
-
+		// retrieve 2d array of local tax data from the workbook of the tax year
+		workbook, err = sourcefileutils.SelectWorkbook(workbooks, sourcefileutils.LOCAL_WORKBOOK, taxYear)
 		if err != nil {
 			logger.Error(getDataErrorStr("local tax", err))
 		}
 
-		err = engine.LoadLocalTaxTable(localTaxData, c)
+		// the jurisdictions are matched to the census counties using the county files and the match settings
+		stageChecksum = extract.GetStageChecksum(append(extract.GetWorkbookChecksumParts(workbook), censusChecksum,
+			getOptionalFileChecksum(overridesFile), getOptionalFileChecksum(placeCountyFile), fmt.Sprint(matchThresh),
+			payFrequency, nullString)...)
+		if !skipStage(engine, "4", stageChecksum, force) {
+			startStage(engine, runId, "4", stageChecksum)
+
+			// jurisdictions already loaded keep their ids
+			var taxLocaleIds map[string]int32
+			taxLocaleIds, err = engine.TaxLocaleIds()
+			if err != nil {
+				logger.Error(getDataErrorStr("loaded tax locale id", err))
+			}
+
+			localTaxData, localTaxCounties, err = extract.GetLocalTaxData(workbook, censusData, matchThresh, matchWorkers, payFrequency, overridesFile, placeCountyFile, taxLocaleIds, nullString)
+
+			if err != nil {
+				logger.Error(getDataErrorStr("local tax", err))
+			}
+
+			manifestId, err = engine.LoadSourceManifest(extract.GetWorkbookManifest(runId, workbook))
+			if err != nil {
+				logger.Error(getLoadErrorStr("source manifest", err))
+			}
+
+			err = engine.LoadLocalTaxTable(localTaxData, manifestId, c)
+
+			if err != nil {
+				logger.Error(getLoadErrorStr("local tax", err))
+			}
+
+			// every loaded locale has its links replaced, including those no longer linked to a county
+			var localeIds []string
+			for _, row := range localTaxData {
+				localeIds = append(localeIds, row[0])
+			}
+			err = engine.LoadLocalTaxCountyTable(localeIds, localTaxCounties, manifestId, c)
+
+			if err != nil {
+				logger.Error(getLoadErrorStr("local tax county", err))
+			}
+
+			finishStage(engine, runId, "4")
+		}
 
-		if err != nil {
-			logger.Error(getLoadErrorStr("local tax", err))
+	}
+
+}
+
+// fetch the workbooks of the sources config into the cache and point the active sources to them. Only updates the
+// pointer if every source is fetched and read successfully.
+func fetchSources(args []string, sourcesConfig string, baseUrl string, cacheDir string, activeFile string, attempts int) {
+	fetchFlags := flag.NewFlagSet("fetch-sources", flag.ExitOnError)
+	base := fetchFlags.String("base-url", baseUrl, "base-url, Base url the relative urls of the sources config are resolved against, i.e a local file server")
+	config := fetchFlags.String("sources", sourcesConfig, "sources, Sources config listing the url, type and year of each workbook to fetch")
+	fetchFlags.Parse(args)
+
+	sources, err := extract.ReadSourcesConfig(*config)
+	if err != nil {
+		logger.Error("Unable to read the sources config. Recieved error: %s", err)
+	}
+
+	workbooks, err := extract.FetchSources(sources, *base, cacheDir, attempts)
+	if err != nil {
+		logger.Error("Unable to fetch the sources. Recieved error: %s", err)
+	}
+
+	err = sourcefileutils.WriteActiveSources(activeFile, workbooks, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		logger.Error("Unable to update the active sources. Recieved error: %s", err)
+	}
+
+	logger.Info("Fetched %v sources", len(workbooks))
+}
+
+// helper method to estimate the federal, state and local taxes of the household given by the args from the loaded
+// tables, and print the estimate with its breakdown
+func estimate(args []string, engine *load.DbEngine) {
+	estimateFlags := flag.NewFlagSet("estimate", flag.ExitOnError)
+	income := estimateFlags.Float64("income", 0, "income, Gross income of the household, taken to be wages")
+	status := estimateFlags.String("status", "single", "status, Filing status, by name or id, i.e \"married filing jointly\" or 2")
+	dependents := estimateFlags.Int("dependents", 0, "dependents, Dependent children of the household")
+	conditions := estimateFlags.Int("conditions", 0, "conditions, Conditions of age 65 or over or blindness of the filer and spouse")
+	county := estimateFlags.Int("county", 0, "county, FIPS code of the county of residence, taxed by its county level jurisdictions")
+	locale := estimateFlags.Int("locale", 0, "locale, Id of the tax locale of residence, taking precedence over the county")
+	year := estimateFlags.Int("year", 0, "year, Tax year of the federal tables to estimate with. 0 uses the latest year loaded.")
+	estimateFlags.Parse(args)
+
+	if *county == 0 && *locale == 0 {
+		logger.Error("A county or tax locale is needed to estimate the state and local taxes")
+	}
+
+	data, err := calculator.ReadTaxData(engine.Connection(), *year)
+	if err != nil {
+		logger.Error("Unable to read the tax tables. Recieved error: %s", err)
+	}
+
+	statusId, err := getFilingStatusId(data, *status)
+	if err != nil {
+		logger.Error("Unable to estimate the taxes. Recieved error: %s", err)
+	}
+
+	e, err := data.Estimate(calculator.Input{GrossIncome: *income, FilingStatus: statusId, Dependents: *dependents,
+		Conditions: *conditions, CountyId: *county, TaxLocaleId: *locale})
+	if err != nil {
+		logger.Error("Unable to estimate the taxes. Recieved error: %s", err)
+	}
+
+	printEstimate(e)
+}
+
+// helper method returning the id of a filing status given by name or id
+func getFilingStatusId(data *calculator.TaxData, status string) (int, error) {
+	if id, err := strconv.Atoi(status); err == nil {
+		return id, nil
+	}
+
+	for id, name := range data.FilingStatuses {
+		if strings.EqualFold(name, strings.TrimSpace(status)) {
+			return id, nil
 		}
+	}
+
+	return 0, fmt.Errorf("There is no filing status named %s", status)
+}
 
+// helper method to print an estimate, a section per jurisdiction listing its deductions and lines
+func printEstimate(e calculator.Estimate) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Tax year %v\t\t\t\t\n", e.TaxYear)
+
+	jurisdictions := append([]calculator.JurisdictionEstimate{e.Federal, e.State}, e.Local...)
+	for _, j := range jurisdictions {
+		fmt.Fprintf(w, "\t\t\t\t\n%s\tbase\trate\tamount\t\n", j.Jurisdiction)
+		for _, line := range j.Deductions {
+			fmt.Fprintf(w, "  %s\t\t\t%.2f\t\n", line.Item, line.Amount)
+		}
+		fmt.Fprintf(w, "  taxable income\t\t\t%.2f\t\n", j.TaxableIncome)
+		for _, line := range j.Lines {
+			rate := ""
+			if line.Rate != 0 {
+				rate = fmt.Sprintf("%.4g%%", line.Rate*100)
+			}
+			fmt.Fprintf(w, "  %s\t%.2f\t%s\t%.2f\t\n", line.Item, line.Base, rate, line.Amount)
+		}
+		fmt.Fprintf(w, "  tax\t\t\t%.2f\t\n", j.Tax)
+	}
+	fmt.Fprintf(w, "\t\t\t\t\ntotal tax\t\t\t%.2f\t\n", e.Total)
+	w.Flush()
+
+	for _, note := range e.Notes {
+		fmt.Println("note: " + note)
+	}
+}
+
+// helper method returning whether a stage can be skipped, as its sources and settings are unchanged since its last
+// successful run. A forced stage is never skipped.
+func skipStage(engine *load.DbEngine, stage string, checksum string, force bool) bool {
+	if force {
+		return false
+	}
+
+	lastChecksum, err := engine.LastStageChecksum(stage)
+	if err != nil {
+		logger.Warn("Unable to retrieve the last run of stage %s, so it is run. Recieved error: %s", stage, err)
+		return false
+	}
+
+	if lastChecksum == checksum {
+		logger.Info("The sources of stage %s are unchanged since its last successful run, skipping the stage", stage)
+		return true
+	}
+
+	return false
+}
+
+// helper method to forget the runs of the stages loading tables that reference the tables of a stage being cleared, as
+// clearing the stage's tables deletes their rows through the cascade of their foreign keys
+func resetDependentStages(engine *load.DbEngine, stage string, c bool) {
+	if !c || len(dependentStages[stage]) == 0 {
+		return
+	}
+
+	logger.Info("Clearing the tables of stage %s empties the tables of stage(s) %s, which are run again by their next run",
+		stage, strings.Join(dependentStages[stage], ", "))
+	err := engine.ResetStageRuns(dependentStages[stage])
+	if err != nil {
+		logger.Error(getLoadErrorStr("etl run", err))
+	}
+}
+
+// helper method to record the start of a stage in the etl run table
+func startStage(engine *load.DbEngine, runId string, stage string, checksum string) {
+	err := engine.StartStageRun(runId, stage, checksum)
+	if err != nil {
+		logger.Error(getLoadErrorStr("etl run", err))
+	}
+}
+
+// helper method to record the success of a stage in the etl run table
+func finishStage(engine *load.DbEngine, runId string, stage string) {
+	err := engine.FinishStageRun(runId, stage)
+	if err != nil {
+		logger.Error(getLoadErrorStr("etl run", err))
+	}
+}
+
+// helper method to return the checksum of an optional file, empty if no file is given
+func getOptionalFileChecksum(filePath string) string {
+	if filePath == "" {
+		return ""
+	}
+
+	checksum, err := sourcefileutils.FileChecksum(filePath)
+	if err != nil {
+		logger.Error(getDataErrorStr("source file checksum", err))
 	}
 
+	return checksum
 }
 
 // helper method to return error string for a load error
@@ -0,0 +1,186 @@
+/* Holds utility functions for parsing the percentages and currency amounts of source cells into exact decimals */
+
+package parseutils
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// error returned for a cell holding no value, such as an empty cell, "none" or "n.a."
+var ErrNoValue = errors.New("the cell holds no value")
+
+// typed error returned when a cell holds text that is not a value of the expected kind
+type CellParseError struct {
+	Cell   string
+	Reason string
+}
+
+func (e *CellParseError) Error() string {
+	return fmt.Sprintf("unable to parse cell %q: %s", e.Cell, e.Reason)
+}
+
+// a decimal number parsed from a cell, held exactly. Rates are fractions, i.e 0.0575 for "5.75%", and amounts are
+// dollars.
+type Decimal struct {
+	r *big.Rat
+}
+
+// return the decimal with as many digits after the decimal point as it needs, i.e "0.095" or "1500.5"
+func (d Decimal) String() string {
+	if d.r == nil {
+		return "0"
+	}
+
+	// a decimal parsed from text has a denominator of powers of 2 and 5, so a power of 10 makes it whole
+	places := 0
+	scaled := new(big.Rat).Set(d.r)
+	for !scaled.IsInt() {
+		scaled.Mul(scaled, big.NewRat(10, 1))
+		places++
+	}
+
+	return d.r.FloatString(places)
+}
+
+// return the decimal as a rational number
+func (d Decimal) Rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Set(d.r)
+}
+
+// return the decimal as a whole number, and whether it is one. A decimal with a fraction, i.e "1500.5", is not.
+func (d Decimal) Int64() (int64, bool) {
+	r := d.Rat()
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, false
+	}
+
+	return r.Num().Int64(), true
+}
+
+// compare the decimal to another, returning -1, 0 or 1
+func (d Decimal) Cmp(o Decimal) int {
+	return d.Rat().Cmp(o.Rat())
+}
+
+// footnote markers of the Tax Foundation sheets, i.e "(a)", "(a, e)" or "[w]"
+var footnoteMarker = regexp.MustCompile(`(?i)[(\[]\s*[a-z]{1,3}(?:\s*,\s*[a-z]{1,3})*\s*[)\]]`)
+
+// a letter of a footnote marker, i.e "a" or "gg"
+var footnoteLetter = regexp.MustCompile(`(?i)[a-z]{1,3}`)
+
+// split a cell into its text and the letters of its footnote markers in order, i.e "$777 credit (e, i)" into
+// "$777 credit" and ["e", "i"]. Letters are lower case and the text is trimmed of whitespace.
+func SplitFootnoteMarkers(cell string) (string, []string) {
+	var letters []string
+	for _, marker := range footnoteMarker.FindAllString(cell, -1) {
+		for _, letter := range footnoteLetter.FindAllString(marker, -1) {
+			letters = append(letters, strings.ToLower(letter))
+		}
+	}
+
+	return strings.TrimSpace(footnoteMarker.ReplaceAllString(cell, " ")), letters
+}
+
+// a number with an optional sign, dollar sign, thousands separators, decimals and percent sign
+var numberCell = regexp.MustCompile(`^([-−]?)\s*(\$?)\s*([-−]?)\s*([0-9]{1,3}(?:,[0-9]{3})+(?:\.[0-9]*)?|[0-9]+(?:\.[0-9]*)?|\.[0-9]+)\s*(%?)$`)
+
+// a number in parentheses, the accounting notation of a negative amount, i.e "($200)"
+var accountingNegative = regexp.MustCompile(`^\((.*)\)$`)
+
+// cells holding no value, in lower case and without footnote markers
+var noValueCells = map[string]bool{
+	"":     true,
+	"none": true,
+	"n.a.": true,
+	"n.a":  true,
+	"n/a":  true,
+	"na":   true,
+	"-":    true,
+	"–":    true,
+	"—":    true,
+}
+
+// parse a rate cell into a fraction. A cell with a percent sign, i.e "5.75%", is a percentage, and a bare number,
+// i.e "0.0575", is already a fraction. Returns ErrNoValue for a cell holding no value.
+func ParseRate(cell string) (Decimal, error) {
+	n, err := parseNumberCell(cell)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if n.dollar {
+		return Decimal{}, &CellParseError{Cell: cell, Reason: "expected a rate, found an amount"}
+	}
+
+	if n.percent {
+		n.value.Quo(n.value, big.NewRat(100, 1))
+	}
+
+	return Decimal{r: n.value}, nil
+}
+
+// parse a currency amount cell into dollars, i.e "$1,500.50" or "-$200". The dollar sign is optional. Returns
+// ErrNoValue for a cell holding no value.
+func ParseAmount(cell string) (Decimal, error) {
+	n, err := parseNumberCell(cell)
+	if err != nil {
+		return Decimal{}, err
+	}
+	if n.percent {
+		return Decimal{}, &CellParseError{Cell: cell, Reason: "expected an amount, found a rate"}
+	}
+
+	return Decimal{r: n.value}, nil
+}
+
+// a number parsed from a cell, with the symbols it was given with
+type numberValue struct {
+	value   *big.Rat
+	dollar  bool
+	percent bool
+}
+
+// helper method to parse a cell holding a number, ignoring footnote markers and surrounding whitespace
+func parseNumberCell(cell string) (numberValue, error) {
+	text := strings.TrimSpace(footnoteMarker.ReplaceAllString(cell, " "))
+	if noValueCells[strings.ToLower(text)] {
+		return numberValue{}, ErrNoValue
+	}
+
+	negative := false
+	if m := accountingNegative.FindStringSubmatch(text); m != nil {
+		negative = true
+		text = strings.TrimSpace(m[1])
+	}
+
+	m := numberCell.FindStringSubmatch(text)
+	if m == nil {
+		return numberValue{}, &CellParseError{Cell: cell, Reason: "not a number"}
+	}
+	if m[1] != "" && m[3] != "" {
+		return numberValue{}, &CellParseError{Cell: cell, Reason: "more than one sign"}
+	}
+	if (m[1] != "" || m[3] != "") && negative {
+		return numberValue{}, &CellParseError{Cell: cell, Reason: "more than one sign"}
+	}
+	if m[2] != "" && m[5] != "" {
+		return numberValue{}, &CellParseError{Cell: cell, Reason: "both a dollar and a percent sign"}
+	}
+
+	value, ok := new(big.Rat).SetString(strings.ReplaceAll(m[4], ",", ""))
+	if !ok {
+		return numberValue{}, &CellParseError{Cell: cell, Reason: "not a number"}
+	}
+	if negative || m[1] != "" || m[3] != "" {
+		value.Neg(value)
+	}
+
+	return numberValue{value: value, dollar: m[2] != "", percent: m[5] != ""}, nil
+}
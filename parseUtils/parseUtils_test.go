@@ -0,0 +1,152 @@
+package parseutils
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"5.75%", "0.0575"},
+		{"0.0575", "0.0575"},
+		{"4.95% (a)", "0.0495"},
+		{" 3.07 % ", "0.0307"},
+		{"10%", "0.1"},
+		{"0%", "0"},
+		{".5%", "0.005"},
+		{"-1.5%", "-0.015"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRate(tt.cell)
+		if err != nil {
+			t.Errorf("ParseRate(%q) returned error %s", tt.cell, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseRate(%q) = %s, want %s", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		cell string
+		want string
+	}{
+		{"$10,275", "10275"},
+		{"$1,500.50", "1500.5"},
+		{"12950", "12950"},
+		{"$2,500 (a, e)", "2500"},
+		{"$4,400 [w]", "4400"},
+		{"-$200", "-200"},
+		{"$-200", "-200"},
+		{"($200)", "-200"},
+		{"$1,000,000", "1000000"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.cell)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned error %s", tt.cell, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseAmount(%q) = %s, want %s", tt.cell, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		cell string
+		// parse as a rate rather than an amount
+		rate bool
+		// whether the cell holds no value rather than text that is not a value
+		noValue bool
+	}{
+		{"n.a.", false, true},
+		{"none", true, true},
+		{"None (b)", false, true},
+		{"", true, true},
+		{"—", false, true},
+		{"(a, e)", false, true},
+		{"$58 credit", false, false},
+		{"$58 credit", true, false},
+		{"5.75%", false, false},
+		{"$10,275", true, false},
+		{"$5%", true, false},
+		{"--5", false, false},
+		{"-($200)", false, false},
+		{"10,27", false, false},
+		{"1.2.3", true, false},
+	}
+
+	for _, tt := range tests {
+		var err error
+		if tt.rate {
+			_, err = ParseRate(tt.cell)
+		} else {
+			_, err = ParseAmount(tt.cell)
+		}
+
+		var cellErr *CellParseError
+		switch {
+		case tt.noValue && !errors.Is(err, ErrNoValue):
+			t.Errorf("parsing %q returned %v, want ErrNoValue", tt.cell, err)
+		case !tt.noValue && !errors.As(err, &cellErr):
+			t.Errorf("parsing %q returned %v, want a *CellParseError", tt.cell, err)
+		case !tt.noValue && cellErr.Cell != tt.cell:
+			t.Errorf("parsing %q returned an error for cell %q", tt.cell, cellErr.Cell)
+		}
+	}
+}
+
+func TestSplitFootnoteMarkers(t *testing.T) {
+	tests := []struct {
+		cell    string
+		text    string
+		letters []string
+	}{
+		{"$58 credit (a, e)", "$58 credit", []string{"a", "e"}},
+		{"Alabama (a, b, c)", "Alabama", []string{"a", "b", "c"}},
+		{"$777 credit [W]", "$777 credit", []string{"w"}},
+		{"4.25% (gg) (h)", "4.25%", []string{"gg", "h"}},
+		{"$10,275", "$10,275", nil},
+		{"n.a.", "n.a.", nil},
+	}
+
+	for _, tt := range tests {
+		text, letters := SplitFootnoteMarkers(tt.cell)
+		if text != tt.text || !reflect.DeepEqual(letters, tt.letters) {
+			t.Errorf("SplitFootnoteMarkers(%q) = %q, %v, want %q, %v", tt.cell, text, letters, tt.text, tt.letters)
+		}
+	}
+}
+
+func TestDecimalInt64(t *testing.T) {
+	tests := []struct {
+		cell  string
+		want  int64
+		whole bool
+	}{
+		{"$10,275", 10275, true},
+		{"$1,500.50", 0, false},
+		{"$25,900.00", 25900, true},
+	}
+
+	for _, tt := range tests {
+		d, err := ParseAmount(tt.cell)
+		if err != nil {
+			t.Errorf("ParseAmount(%q) returned error %s", tt.cell, err)
+			continue
+		}
+		if got, whole := d.Int64(); got != tt.want || whole != tt.whole {
+			t.Errorf("ParseAmount(%q).Int64() = %v, %v, want %v, %v", tt.cell, got, whole, tt.want, tt.whole)
+		}
+	}
+}
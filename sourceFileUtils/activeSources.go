@@ -0,0 +1,148 @@
+/* Holds utility functions for the active source pointer, the file recording the fetched workbook used for each type and tax year */
+
+package sourcefileutils
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// an entry of the active source pointer
+type activeSource struct {
+	Type      string `yaml:"type"`
+	Year      int    `yaml:"year"`
+	Url       string `yaml:"url"`
+	Path      string `yaml:"path"`
+	Checksum  string `yaml:"checksum"`
+	FetchedAt string `yaml:"fetchedAt"`
+}
+
+// the active source pointer file
+type activeSources struct {
+	Sources []activeSource `yaml:"sources"`
+}
+
+// read in the workbooks of the active source pointer. A missing pointer file has no workbooks. Each workbook is read
+// again, and an error is returned if it no longer matches the checksum it was fetched with.
+func ReadActiveSources(pointerFile string) ([]Workbook, error) {
+	active, err := readActiveSourcesFile(pointerFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var workbooks []Workbook
+	for _, src := range active.Sources {
+		wb, err := DescribeSource(src.Path, src.Type, src.Year)
+		if err != nil {
+			return nil, err
+		}
+		if wb.Checksum != src.Checksum {
+			return nil, fmt.Errorf("The active %s source of %v at %s does not match its checksum %s, fetch the sources again", src.Type, src.Year, src.Path, src.Checksum)
+		}
+		wb.Url = src.Url
+		workbooks = append(workbooks, wb)
+	}
+
+	logger.Info("Read %v active sources from %s", len(workbooks), pointerFile)
+
+	return workbooks, nil
+}
+
+// point the active sources of the types and tax years of the given workbooks to them, keeping the other entries of
+// the pointer file. The file is replaced whole so a failed write leaves the previous pointer.
+func WriteActiveSources(pointerFile string, workbooks []Workbook, fetchedAt string) error {
+	active, err := readActiveSourcesFile(pointerFile)
+	if err != nil {
+		return err
+	}
+
+	for _, wb := range workbooks {
+		src := activeSource{Type: wb.Type, Year: wb.Year, Url: wb.Url, Path: wb.FilePath, Checksum: wb.Checksum, FetchedAt: fetchedAt}
+		replaced := false
+		for i, s := range active.Sources {
+			if s.Type == wb.Type && s.Year == wb.Year {
+				active.Sources[i] = src
+				replaced = true
+			}
+		}
+		if !replaced {
+			active.Sources = append(active.Sources, src)
+		}
+	}
+
+	// in order of type and year so the file diffs cleanly
+	sort.Slice(active.Sources, func(i, j int) bool {
+		if active.Sources[i].Type != active.Sources[j].Type {
+			return active.Sources[i].Type < active.Sources[j].Type
+		}
+		return active.Sources[i].Year < active.Sources[j].Year
+	})
+
+	b, err := yaml.Marshal(active)
+	if err != nil {
+		return fmt.Errorf("There was an error writing the active source pointer %s: %s", pointerFile, err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(pointerFile), ".active-*")
+	if err != nil {
+		return fmt.Errorf("There was an error writing the active source pointer %s: %s", pointerFile, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), pointerFile)
+	}
+	if err != nil {
+		return fmt.Errorf("There was an error writing the active source pointer %s: %s", pointerFile, err)
+	}
+
+	logger.Info("Pointed %v active sources in %s", len(workbooks), pointerFile)
+
+	return nil
+}
+
+// return the given workbooks with the active workbooks in place of those of the same type and tax year
+func MergeActiveSources(workbooks []Workbook, active []Workbook) []Workbook {
+	merged := append([]Workbook{}, active...)
+	for _, wb := range workbooks {
+		replaced := false
+		for _, a := range active {
+			if a.Type == wb.Type && a.Year == wb.Year {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, wb)
+		}
+	}
+
+	return merged
+}
+
+// helper method to read in the active source pointer file, empty if it does not exist
+func readActiveSourcesFile(pointerFile string) (activeSources, error) {
+	var active activeSources
+	b, err := ioutil.ReadFile(pointerFile)
+	if os.IsNotExist(err) {
+		return active, nil
+	} else if err != nil {
+		return active, fmt.Errorf("There was an error reading the active source pointer %s: %s", pointerFile, err)
+	}
+
+	err = yaml.Unmarshal(b, &active)
+	if err != nil {
+		return active, fmt.Errorf("There was an error parsing the active source pointer %s: %s", pointerFile, err)
+	}
+
+	return active, nil
+}
@@ -0,0 +1,306 @@
+/* Holds utility functions for discovering the Tax Foundation workbooks in a directory and the tax year of each */
+
+package sourcefileutils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// types of source workbooks
+const (
+	FEDERAL_WORKBOOK string = "federal"
+	STATE_WORKBOOK   string = "state"
+	LOCAL_WORKBOOK   string = "local"
+)
+
+// sheets identifying the type of a workbook whose file name does not
+const (
+	federalBracketSheet string = "Table 1"
+	localTaxSheet       string = "Local Income Tax Rates"
+)
+
+// a tax year as it appears in file names, sheet names and titles
+var taxYear = regexp.MustCompile(`(?:^|[^0-9])((?:19|20)[0-9]{2})(?:[^0-9]|$)`)
+
+// a sheet holding the data of one tax year of a workbook
+type Workbook struct {
+	Type     string
+	Year     int
+	FilePath string
+	// sheet holding the year's data. The federal workbook spreads its data over several tables, so it is empty.
+	Sheet string
+	// year the workbook was published, which may be later than the tax year of the sheet
+	Published int
+	// hex encoded SHA-256 checksum of the workbook file
+	Checksum string
+	// url the workbook was fetched from, empty if it was not fetched
+	Url string
+}
+
+// scan the given directory for Tax Foundation workbooks in any format with a source reader, returning one entry per
+// type and tax year found. The type and year are taken from the file name if given there, else from the sheets of
+// the workbook. Workbooks of unknown type are skipped. A workbook with a sheet per year, such as the state workbook,
+// has an entry for each year.
+func DiscoverWorkbooks(dir string) ([]Workbook, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading the source directory %s: %s", dir, err)
+	}
+
+	var workbooks []Workbook
+	for _, file := range files {
+		// skip directories, files without a reader and the lock files of open workbooks
+		if file.IsDir() || !IsSourceFile(file.Name()) || strings.HasPrefix(file.Name(), "~$") || strings.HasPrefix(file.Name(), ".~lock") {
+			continue
+		}
+
+		found, err := describeWorkbook(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		workbooks = append(workbooks, found...)
+	}
+
+	logger.Info("Discovered %v source workbook sheets in %s", len(workbooks), dir)
+
+	return workbooks, nil
+}
+
+// return the workbook of the given type for the given tax year. A year of 0 selects the latest year. If the type was
+// not published for the year, the latest earlier year is used.
+func SelectWorkbook(workbooks []Workbook, workbookType string, year int) (Workbook, error) {
+	var candidates []Workbook
+	for _, wb := range workbooks {
+		if wb.Type == workbookType && (year == 0 || wb.Year <= year) {
+			candidates = append(candidates, wb)
+		}
+	}
+
+	if len(candidates) == 0 {
+		if year == 0 {
+			return Workbook{}, fmt.Errorf("No %s workbook was found", workbookType)
+		}
+		return Workbook{}, fmt.Errorf("No %s workbook was found for %v or an earlier year", workbookType, year)
+	}
+
+	// latest year first. Ties are broken by file name so the choice is stable.
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Year != candidates[j].Year {
+			return candidates[i].Year > candidates[j].Year
+		}
+		return candidates[i].FilePath > candidates[j].FilePath
+	})
+
+	wb := candidates[0]
+	if year != 0 && wb.Year != year {
+		logger.Warn("No %s workbook was found for %v, using the one for %v", workbookType, year, wb.Year)
+	}
+	logger.Info("Using the %s workbook %s for %v", workbookType, wb.FilePath, wb.Year)
+
+	return wb, nil
+}
+
+// helper method to work out the type and tax years of a workbook
+func describeWorkbook(filePath string) ([]Workbook, error) {
+	f, err := NewSourceReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	checksum, err := FileChecksum(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.ToLower(filepath.Base(filePath))
+	sheets := f.Sheets()
+	yearSheets := findYearSheets(sheets)
+
+	workbookType := ""
+	switch {
+	case strings.Contains(name, "federal"):
+		workbookType = FEDERAL_WORKBOOK
+	case strings.Contains(name, "local"):
+		workbookType = LOCAL_WORKBOOK
+	case strings.Contains(name, "state"):
+		workbookType = STATE_WORKBOOK
+	case hasSheet(sheets, localTaxSheet):
+		workbookType = LOCAL_WORKBOOK
+	case hasSheet(sheets, federalBracketSheet):
+		workbookType = FEDERAL_WORKBOOK
+	case len(yearSheets) > 0:
+		workbookType = STATE_WORKBOOK
+	default:
+		logger.Warn("Skipping the workbook %s as its type is unknown", filePath)
+		return nil, nil
+	}
+
+	return describeTypedWorkbook(f, filePath, workbookType, findYear(name), checksum), nil
+}
+
+// return the entry of a source file of the given type and tax year, as fetched under a name not describing it. An
+// error is returned if the file cannot be read or does not hold the sheet of the year.
+func DescribeSource(filePath string, workbookType string, year int) (Workbook, error) {
+	f, err := NewSourceReader(filePath)
+	if err != nil {
+		return Workbook{}, err
+	}
+	defer f.Close()
+
+	checksum, err := FileChecksum(filePath)
+	if err != nil {
+		return Workbook{}, err
+	}
+
+	for _, wb := range describeTypedWorkbook(f, filePath, workbookType, year, checksum) {
+		if wb.Year != year {
+			continue
+		}
+
+		// the federal workbook spreads its data over several tables, the first of which must be present
+		sheet := wb.Sheet
+		if workbookType == FEDERAL_WORKBOOK {
+			sheet = federalBracketSheet
+		}
+		rows, err := f.Rows(sheet)
+		if err != nil || len(rows) == 0 {
+			return Workbook{}, fmt.Errorf("The %s source file %s has no rows in the sheet %s", workbookType, filePath, sheet)
+		}
+
+		return wb, nil
+	}
+
+	return Workbook{}, fmt.Errorf("The source file %s does not hold the %s data of %v", filePath, workbookType, year)
+}
+
+// helper method to work out the tax years of a workbook of a known type, using the year in its file name if not 0
+func describeTypedWorkbook(f SourceReader, filePath string, workbookType string, fileYear int, checksum string) []Workbook {
+	sheets := f.Sheets()
+	yearSheets := findYearSheets(sheets)
+
+	switch workbookType {
+	case STATE_WORKBOOK:
+		// a workbook of a single sheet, such as a comma delimited file, holds the year in its file name
+		if len(yearSheets) == 0 && fileYear != 0 && len(sheets) == 1 {
+			return describedWorkbook(filePath, STATE_WORKBOOK, fileYear, sheets[0], checksum)
+		}
+
+		// each sheet holds a year of the state data. Unless named in the file, the workbook was published with its
+		// latest year.
+		published := fileYear
+		for _, sheet := range yearSheets {
+			if fileYear == 0 && findYear(sheet) > published {
+				published = findYear(sheet)
+			}
+		}
+		var workbooks []Workbook
+		for _, sheet := range yearSheets {
+			workbooks = append(workbooks, Workbook{Type: STATE_WORKBOOK, Year: findYear(sheet), FilePath: filePath, Sheet: sheet,
+				Published: published, Checksum: checksum})
+		}
+		if len(workbooks) == 0 {
+			logger.Warn("Skipping the state workbook %s as it has no sheet named by year", filePath)
+		}
+		return workbooks
+	case LOCAL_WORKBOOK:
+		sheet := localTaxSheet
+		if !hasSheet(sheets, sheet) && len(sheets) > 0 {
+			sheet = sheets[0]
+		}
+		year := fileYear
+		if year == 0 {
+			year = findTitleYear(f, sheet)
+		}
+		return describedWorkbook(filePath, LOCAL_WORKBOOK, year, sheet, checksum)
+	default:
+		year := fileYear
+		if year == 0 {
+			year = findTitleYear(f, federalBracketSheet)
+		}
+		return describedWorkbook(filePath, FEDERAL_WORKBOOK, year, "", checksum)
+	}
+}
+
+// helper method returning the entry of a workbook with a single year, none if the year is unknown. A workbook with a
+// single year is published for that year.
+func describedWorkbook(filePath, workbookType string, year int, sheet string, checksum string) []Workbook {
+	if year == 0 {
+		logger.Warn("Skipping the %s workbook %s as its tax year is unknown", workbookType, filePath)
+		return nil
+	}
+
+	return []Workbook{{Type: workbookType, Year: year, FilePath: filePath, Sheet: sheet, Published: year, Checksum: checksum}}
+}
+
+// return the hex encoded SHA-256 checksum of a file
+func FileChecksum(filePath string) (string, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("There was an error reading the file %s to checksum it: %s", filePath, err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// helper method returning the year in the title of a sheet, the first cell holding a year in its first rows.
+// Returns 0 if there is none.
+func findTitleYear(f SourceReader, sheet string) int {
+	rows, err := f.Rows(sheet)
+	if err != nil {
+		return 0
+	}
+
+	for i := 0; i < len(rows) && i < 3; i++ {
+		for _, cell := range rows[i] {
+			if year := findYear(cell); year != 0 {
+				return year
+			}
+		}
+	}
+
+	return 0
+}
+
+// helper method returning the sheets named by year
+func findYearSheets(sheets []string) []string {
+	var yearSheets []string
+	for _, sheet := range sheets {
+		if _, err := strconv.Atoi(strings.TrimSpace(sheet)); err == nil && findYear(sheet) != 0 {
+			yearSheets = append(yearSheets, sheet)
+		}
+	}
+
+	return yearSheets
+}
+
+// helper method returning the first tax year in a string, 0 if there is none
+func findYear(s string) int {
+	m := taxYear.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+
+	year, _ := strconv.Atoi(m[1])
+	return year
+}
+
+// helper method returning whether a sheet of the given name is in the list of sheets
+func hasSheet(sheets []string, sheet string) bool {
+	for _, s := range sheets {
+		if strings.EqualFold(strings.TrimSpace(s), sheet) {
+			return true
+		}
+	}
+
+	return false
+}
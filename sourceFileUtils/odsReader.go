@@ -0,0 +1,185 @@
+/* Holds the reader of OpenDocument spreadsheets, read from the content.xml of the zipped document */
+
+package sourcefileutils
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// namespaces of the elements and attributes of an OpenDocument spreadsheet
+const (
+	odsTableNs  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNs   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	odsOfficeNs = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+)
+
+// reader of OpenDocument spreadsheets. The whole document is read on open.
+func newOdsReader(filePath string) (SourceReader, error) {
+	z, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer z.Close()
+
+	for _, f := range z.File {
+		if f.Name != "content.xml" {
+			continue
+		}
+
+		content, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer content.Close()
+
+		return readOdsContent(content)
+	}
+
+	return nil, fmt.Errorf("the document has no content.xml")
+}
+
+// helper method to read the tables of the content of an OpenDocument spreadsheet as sheets. Repeated rows and cells
+// are expanded, except for trailing empty ones which documents repeat up to the size of the sheet.
+func readOdsContent(content io.Reader) (SourceReader, error) {
+	r := &memoryReader{rows: make(map[string][][]string)}
+	d := xml.NewDecoder(content)
+
+	var sheet string
+	var rows [][]string
+	// empty rows not yet added, added only if a non-empty row follows
+	pendingRows := 0
+	var row []string
+	rowRepeat := 1
+	// empty cells not yet added, added only if a non-empty cell follows
+	pendingCells := 0
+	var cell strings.Builder
+	cellRepeat := 1
+	// depth of text paragraphs, the text of a cell is only read within one
+	inParagraph := 0
+	paragraphs := 0
+	// depth of comments on cells, whose text is not part of the cell
+	inAnnotation := 0
+
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsTableNs && t.Name.Local == "table":
+				sheet = odsAttr(t, odsTableNs, "name")
+				rows = nil
+				pendingRows = 0
+			case t.Name.Space == odsTableNs && t.Name.Local == "table-row":
+				row = nil
+				pendingCells = 0
+				rowRepeat = odsRepeat(t, "number-rows-repeated")
+			case t.Name.Space == odsTableNs && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				cell.Reset()
+				paragraphs = 0
+				cellRepeat = odsRepeat(t, "number-columns-repeated")
+				// a value without text is shown as its value
+				if v := odsAttr(t, odsOfficeNs, "value"); v != "" {
+					cell.WriteString(v)
+					paragraphs = -1
+				}
+			case t.Name.Space == odsOfficeNs && t.Name.Local == "annotation":
+				inAnnotation++
+			case t.Name.Space == odsTextNs && t.Name.Local == "p" && inAnnotation == 0:
+				if paragraphs == -1 {
+					// the text of the cell is shown instead of its value
+					cell.Reset()
+					paragraphs = 0
+				}
+				if paragraphs > 0 {
+					cell.WriteString("\n")
+				}
+				paragraphs++
+				inParagraph++
+			case t.Name.Space == odsTextNs && t.Name.Local == "s" && inParagraph > 0:
+				n := odsRepeat(t, "c")
+				cell.WriteString(strings.Repeat(" ", n))
+			case t.Name.Space == odsTextNs && t.Name.Local == "tab" && inParagraph > 0:
+				cell.WriteString("\t")
+			case t.Name.Space == odsTextNs && t.Name.Local == "line-break" && inParagraph > 0:
+				cell.WriteString("\n")
+			}
+		case xml.CharData:
+			if inParagraph > 0 {
+				cell.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odsOfficeNs && t.Name.Local == "annotation":
+				inAnnotation--
+			case t.Name.Space == odsTextNs && t.Name.Local == "p" && inAnnotation == 0:
+				inParagraph--
+			case t.Name.Space == odsTableNs && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				text := cell.String()
+				if text == "" {
+					pendingCells += cellRepeat
+					continue
+				}
+				for ; pendingCells > 0; pendingCells-- {
+					row = append(row, "")
+				}
+				for i := 0; i < cellRepeat; i++ {
+					row = append(row, text)
+				}
+			case t.Name.Space == odsTableNs && t.Name.Local == "table-row":
+				if len(row) == 0 {
+					pendingRows += rowRepeat
+					continue
+				}
+				// empty rows are nil, as the excel reader reads them
+				for ; pendingRows > 0; pendingRows-- {
+					rows = append(rows, nil)
+				}
+				for i := 0; i < rowRepeat; i++ {
+					rows = append(rows, append([]string{}, row...))
+				}
+			case t.Name.Space == odsTableNs && t.Name.Local == "table":
+				r.sheets = append(r.sheets, sheet)
+				r.rows[sheet] = rows
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// helper method returning the value of an attribute of an element, empty if not given
+func odsAttr(e xml.StartElement, space, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Space == space && a.Name.Local == local {
+			return a.Value
+		}
+	}
+
+	return ""
+}
+
+// helper method returning the repeat count given by an attribute of an element, 1 if not given
+func odsRepeat(e xml.StartElement, local string) int {
+	space := odsTableNs
+	if local == "c" {
+		space = odsTextNs
+	}
+
+	n, err := strconv.Atoi(odsAttr(e, space, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+
+	return n
+}
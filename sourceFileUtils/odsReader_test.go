@@ -0,0 +1,108 @@
+package sourcefileutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// helper method wrapping the rows of a table in the content of an OpenDocument spreadsheet
+func odsTestContent(tables ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>` + strings.Join(tables, "") + `</office:spreadsheet></office:body>
+</office:document-content>`
+}
+
+func TestReadOdsContent(t *testing.T) {
+	tests := []struct {
+		name  string
+		table string
+		want  [][]string
+	}{
+		{
+			name: "repeated rows and columns",
+			table: `<table:table-row table:number-rows-repeated="2">
+				<table:table-cell table:number-columns-repeated="2"><text:p>2.00%</text:p></table:table-cell>
+				<table:table-cell table:number-columns-repeated="2"/>
+				<table:table-cell><text:p>x</text:p></table:table-cell>
+			</table:table-row>`,
+			want: [][]string{{"2.00%", "2.00%", "", "", "x"}, {"2.00%", "2.00%", "", "", "x"}},
+		},
+		{
+			name: "trailing empty rows and columns repeated to the size of the sheet",
+			table: `<table:table-row>
+				<table:table-cell><text:p>State</text:p></table:table-cell>
+				<table:table-cell table:number-columns-repeated="16383"/>
+			</table:table-row>
+			<table:table-row table:number-rows-repeated="1048575">
+				<table:table-cell table:number-columns-repeated="16384"/>
+			</table:table-row>`,
+			want: [][]string{{"State"}},
+		},
+		{
+			name: "empty rows between rows",
+			table: `<table:table-row><table:table-cell><text:p>a</text:p></table:table-cell></table:table-row>
+			<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="4"/></table:table-row>
+			<table:table-row><table:table-cell/><table:table-cell><text:p>b</text:p></table:table-cell></table:table-row>`,
+			want: [][]string{{"a"}, nil, nil, {"", "b"}},
+		},
+		{
+			name: "cells spanning several lines",
+			table: `<table:table-row>
+				<table:table-cell><text:p>(a) Local income taxes</text:p><text:p>are excluded.</text:p></table:table-cell>
+				<table:table-cell><text:p>line<text:line-break/>break</text:p></table:table-cell>
+			</table:table-row>`,
+			want: [][]string{{"(a) Local income taxes\nare excluded.", "line\nbreak"}},
+		},
+		{
+			name: "spaces, tabs, values and comments",
+			table: `<table:table-row>
+				<table:table-cell><text:p>Gadsden<text:s text:c="3"/>city<text:tab/>(a)</text:p></table:table-cell>
+				<table:table-cell office:value-type="float" office:value="0.0575"/>
+				<table:table-cell office:value-type="percentage" office:value="0.0575"><text:p>5.75%</text:p></table:table-cell>
+				<table:table-cell><office:annotation><text:p>a comment</text:p></office:annotation><text:p>Bessemer</text:p></table:table-cell>
+				<table:covered-table-cell/>
+				<table:table-cell><text:p>after a merged cell</text:p></table:table-cell>
+			</table:table-row>`,
+			want: [][]string{{"Gadsden   city\t(a)", "0.0575", "5.75%", "Bessemer", "", "after a merged cell"}},
+		},
+	}
+
+	for _, tt := range tests {
+		r, err := readOdsContent(strings.NewReader(odsTestContent(`<table:table table:name="Sheet1">` + tt.table + `</table:table>`)))
+		if err != nil {
+			t.Errorf("%s: readOdsContent returned error %s", tt.name, err)
+			continue
+		}
+		rows, err := r.Rows("Sheet1")
+		if err != nil {
+			t.Errorf("%s: Rows returned error %s", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(rows, tt.want) {
+			t.Errorf("%s: rows = %q, want %q", tt.name, rows, tt.want)
+		}
+	}
+}
+
+func TestReadOdsSheets(t *testing.T) {
+	r, err := readOdsContent(strings.NewReader(odsTestContent(
+		`<table:table table:name="2022"><table:table-row><table:table-cell><text:p>a</text:p></table:table-cell></table:table-row></table:table>`,
+		`<table:table table:name="2021"><table:table-row><table:table-cell><text:p>b</text:p></table:table-cell></table:table-row></table:table>`)))
+	if err != nil {
+		t.Fatalf("readOdsContent returned error %s", err)
+	}
+
+	if sheets := r.Sheets(); !reflect.DeepEqual(sheets, []string{"2022", "2021"}) {
+		t.Errorf("Sheets() = %q, want the sheets in the order of the document", sheets)
+	}
+	if rows, _ := r.Rows("2021"); !reflect.DeepEqual(rows, [][]string{{"b"}}) {
+		t.Errorf("Rows(2021) = %q, want the rows of its own table", rows)
+	}
+	if _, err := r.Rows("2020"); err == nil {
+		t.Errorf("Rows(2020) of a document without the sheet returned no error")
+	}
+}
@@ -0,0 +1,175 @@
+/* Holds utility functions for reading the rows of a source sheet as records addressed by column name */
+
+package sourcefileutils
+
+import (
+	"fmt"
+	"strings"
+
+	fuzzy "github.com/paul-mannino/go-fuzzywuzzy"
+)
+
+// least fuzzy ratio of a header cell to one of the labels of a column for the cell to be taken as that column
+const LABEL_MATCH_THRESHOLD = 85
+
+// a column expected in the header of a sheet
+type Column struct {
+	// name the column is addressed by in the records
+	Name string
+	// labels the column may be given in the header, matched ignoring case, whitespace and small differences
+	Labels []string
+	// label of the group the column sits under in a two row header, i.e "Single Filer" for the rates of single
+	// filers. A group spans from its cell to the next group. Empty if the header is a single row.
+	Group string
+	// whether the sheet cannot be read without the column
+	Required bool
+	// for a column without a header cell, such as the row labels of a table, the name of the column it is placed
+	// relative to and its offset from that column, i.e -1 for the column just before it. Labels are ignored.
+	RelativeTo string
+	Offset     int
+}
+
+// a row of a sheet below its header
+type Record struct {
+	// position of the row in the sheet
+	Row     int
+	cells   []string
+	columns map[string]int
+}
+
+// return the cell of the record in the named column. Empty if the cell is empty or the column is not in the sheet.
+func (r Record) Get(name string) string {
+	i, ok := r.columns[name]
+	if !ok || i >= len(r.cells) {
+		return ""
+	}
+
+	return r.cells[i]
+}
+
+// return whether the named column was found in the header of the sheet
+func (r Record) Has(name string) bool {
+	_, ok := r.columns[name]
+	return ok
+}
+
+// return whether every cell of the record is empty
+func (r Record) IsEmpty() bool {
+	for _, cell := range r.cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// read in a sheet of a source file as records addressed by the names of the given columns. The header is the first
+// row holding every required column, and the records are the rows below it. An error naming the missing columns is
+// returned if no row holds all of the required columns.
+func OpenSourceTable(filePath string, sheet string, columns []Column) ([]Record, error) {
+	rows, err := OpenSourceSheet(filePath, sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	// the row missing the fewest required columns, to report if no header is found
+	var missing []string
+	for i, row := range rows {
+		var groupRow []string
+		if i > 0 {
+			groupRow = rows[i-1]
+		}
+
+		found, rowMissing := findColumns(row, groupRow, columns)
+		if len(rowMissing) == 0 {
+			records := make([]Record, 0, len(rows)-i-1)
+			for j := i + 1; j < len(rows); j++ {
+				records = append(records, Record{Row: j, cells: rows[j], columns: found})
+			}
+			logger.Info("Found the header of sheet %s of %s in row %v", sheet, filePath, i)
+
+			return records, nil
+		}
+
+		if missing == nil || len(rowMissing) < len(missing) {
+			missing = rowMissing
+		}
+	}
+
+	return nil, fmt.Errorf("Sheet %s of the source file %s is missing the required column(s) %s", sheet, filePath, strings.Join(missing, ", "))
+}
+
+// helper method to find the given columns in a header row, returning the position of each column found and the
+// names of the required columns not found. Each cell is taken by at most one column, in the order of the columns.
+func findColumns(header []string, groupRow []string, columns []Column) (map[string]int, []string) {
+	// the group each cell of the header sits under
+	groups := make([]string, len(header))
+	group := ""
+	for i := range header {
+		if i < len(groupRow) && strings.TrimSpace(groupRow[i]) != "" {
+			group = groupRow[i]
+		}
+		groups[i] = group
+	}
+
+	found := make(map[string]int)
+	taken := make(map[int]bool)
+	var missing []string
+	for _, col := range columns {
+		if col.RelativeTo != "" {
+			continue
+		}
+
+		best := -1
+		bestScore := LABEL_MATCH_THRESHOLD - 1
+		for i, cell := range header {
+			if taken[i] || strings.TrimSpace(cell) == "" {
+				continue
+			}
+			if col.Group != "" && labelRatio(col.Group, groups[i]) < LABEL_MATCH_THRESHOLD {
+				continue
+			}
+			for _, label := range col.Labels {
+				if score := labelRatio(label, cell); score > bestScore {
+					best = i
+					bestScore = score
+				}
+			}
+		}
+
+		if best >= 0 {
+			found[col.Name] = best
+			taken[best] = true
+		} else if col.Required {
+			missing = append(missing, col.Name)
+		}
+	}
+
+	// columns without a header cell are placed once the columns they are relative to are found
+	for _, col := range columns {
+		if col.RelativeTo == "" {
+			continue
+		}
+
+		if i, ok := found[col.RelativeTo]; ok && i+col.Offset >= 0 && !taken[i+col.Offset] {
+			found[col.Name] = i + col.Offset
+			taken[i+col.Offset] = true
+		} else if col.Required {
+			missing = append(missing, col.Name)
+		}
+	}
+
+	return found, missing
+}
+
+// helper method returning the fuzzy ratio of a label to a header cell, ignoring case and whitespace
+func labelRatio(label, cell string) int {
+	label = strings.ToLower(strings.Join(strings.Fields(label), " "))
+	cell = strings.ToLower(strings.Join(strings.Fields(cell), " "))
+	if label == cell {
+		return 100
+	}
+
+	return fuzzy.Ratio(label, cell)
+}
@@ -0,0 +1,219 @@
+package sourcefileutils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// columns of the sheets of the source workbooks, as the extractors expect them
+var (
+	localTaxTestColumns = []Column{
+		{Name: "state", Labels: []string{"State"}, Required: true},
+		{Name: "jurisdiction", Labels: []string{"Taxing Jurisdiction", "Jurisdiction"}, Required: true},
+		{Name: "resident", Labels: []string{"Resident"}, Required: true},
+		{Name: "nonresident", Labels: []string{"Nonresident", "Non-resident"}, Required: true},
+	}
+	stateTestColumns = []Column{
+		{Name: "state", Labels: []string{"State"}, Required: true},
+		{Name: "single_rate", Labels: []string{"Rates"}, Group: "Single Filer", Required: true},
+		{Name: "single_bracket", Labels: []string{"Brackets"}, Group: "Single Filer", Required: true},
+		{Name: "married_rate", Labels: []string{"Rates"}, Group: "Married Filing Jointly", Required: true},
+		{Name: "married_bracket", Labels: []string{"Brackets"}, Group: "Married Filing Jointly", Required: true},
+		{Name: "deduction_single", Labels: []string{"Single"}, Group: "Standard Deduction", Required: true},
+		{Name: "deduction_couple", Labels: []string{"Couple"}, Group: "Standard Deduction", Required: true},
+		{Name: "exemption_single", Labels: []string{"Single"}, Group: "Personal Exemption", Required: true},
+		{Name: "exemption_couple", Labels: []string{"Couple"}, Group: "Personal Exemption", Required: true},
+		{Name: "exemption_dependent", Labels: []string{"Dependent"}, Group: "Personal Exemption", Required: true},
+		{Name: "head_rate", Labels: []string{"Rates"}, Group: "Head of Household"},
+		{Name: "head_bracket", Labels: []string{"Brackets"}, Group: "Head of Household"},
+		{Name: "deduction_head", Labels: []string{"Head of Household"}, Group: "Standard Deduction"},
+	}
+	federalBracketTestColumns = []Column{
+		{Name: "rate", Labels: []string{"Rate"}, Required: true},
+		{Name: "single", Labels: []string{"For Unmarried Individuals", "Single"}, Required: true},
+		{Name: "married", Labels: []string{"For Married Individuals Filing Joint Returns", "Married Filing Jointly"}, Required: true},
+		{Name: "head", Labels: []string{"For Heads of Households", "Head of Household"}, Required: true},
+	}
+	federalCapitalGainsTestColumns = []Column{
+		{Name: "single", Labels: []string{"For Unmarried Individuals, Taxable Income Over", "Single"}, Required: true},
+		{Name: "married", Labels: []string{"For Married Individuals Filing Joint Returns, Taxable Income Over", "Married Filing Jointly"}, Required: true},
+		{Name: "head", Labels: []string{"For Heads of Households, Taxable Income Over", "Head of Household"}, Required: true},
+		{Name: "rate", RelativeTo: "single", Offset: -1, Required: true},
+	}
+	federalEitcTestColumns = []Column{
+		{Name: "status", Labels: []string{"Filing Status"}, Required: true},
+		{Name: "children_0", Labels: []string{"No Children", "Zero Children"}, Required: true},
+		{Name: "children_1", Labels: []string{"One Child"}, Required: true},
+		{Name: "children_2", Labels: []string{"Two Children"}, Required: true},
+		{Name: "children_3", Labels: []string{"Three or More Children", "Three Children"}, Required: true},
+		{Name: "parameter", RelativeTo: "status", Offset: 1, Required: true},
+	}
+)
+
+// header rows of the 2022 state sheet, in which each group spans the columns up to the next group
+var (
+	stateTestGroupRow = []string{"", "Single Filer", "", "", "Married Filing Jointly", "", "", "Standard Deduction", "", "Personal Exemption"}
+	stateTestHeader   = []string{"State", "Rates", "", "Brackets", "Rates", "", "Brackets", "Single", "Couple", "Single", "Couple", "Dependent"}
+)
+
+func TestFindColumns(t *testing.T) {
+	tests := []struct {
+		name     string
+		groupRow []string
+		header   []string
+		columns  []Column
+		want     map[string]int
+		missing  []string
+	}{
+		{
+			name:    "local tax sheet",
+			header:  []string{"State", "Taxing Jurisdiction", "Resident", "Nonresident"},
+			columns: localTaxTestColumns,
+			want:    map[string]int{"state": 0, "jurisdiction": 1, "resident": 2, "nonresident": 3},
+		},
+		{
+			name:    "reordered local tax sheet with other labels",
+			header:  []string{"Jurisdiction", "State", "Non-resident", "resident ", "Notes"},
+			columns: localTaxTestColumns,
+			want:    map[string]int{"state": 1, "jurisdiction": 0, "resident": 3, "nonresident": 2},
+		},
+		{
+			name:    "local tax sheet missing a required column",
+			header:  []string{"State", "Taxing Jurisdiction", "Resident"},
+			columns: localTaxTestColumns,
+			want:    map[string]int{"state": 0, "jurisdiction": 1, "resident": 2},
+			missing: []string{"nonresident"},
+		},
+		{
+			name:     "state sheet of 2022",
+			groupRow: stateTestGroupRow,
+			header:   stateTestHeader,
+			columns:  stateTestColumns,
+			want: map[string]int{"state": 0, "single_rate": 1, "single_bracket": 3, "married_rate": 4, "married_bracket": 6,
+				"deduction_single": 7, "deduction_couple": 8, "exemption_single": 9, "exemption_couple": 10, "exemption_dependent": 11},
+		},
+		{
+			name:     "state sheet of 2018, with a blank column after each group",
+			groupRow: []string{"", "Single Filer", "", "", "", "Married Filing Jointly", "", "", "", "Standard Deduction", "", "", "Personal Exemption"},
+			header:   []string{"State", "Rates", "", "Brackets", "", "Rates", "", "Brackets", "", "Single", "Couple", "", "Single", "Couple", "Dependent"},
+			columns:  stateTestColumns,
+			want: map[string]int{"state": 0, "single_rate": 1, "single_bracket": 3, "married_rate": 5, "married_bracket": 7,
+				"deduction_single": 9, "deduction_couple": 10, "exemption_single": 12, "exemption_couple": 13, "exemption_dependent": 14},
+		},
+		{
+			name: "state sheet with the optional head of household columns",
+			groupRow: []string{"", "Single Filer", "", "", "Married Filing Jointly", "", "", "Head of Household", "", "",
+				"Standard Deduction", "", "", "Personal Exemption"},
+			header: []string{"State", "Rates", "", "Brackets", "Rates", "", "Brackets", "Rates", "", "Brackets",
+				"Single", "Couple", "Head of Household", "Single", "Couple", "Dependent"},
+			columns: stateTestColumns,
+			want: map[string]int{"state": 0, "single_rate": 1, "single_bracket": 3, "married_rate": 4, "married_bracket": 6,
+				"head_rate": 7, "head_bracket": 9, "deduction_single": 10, "deduction_couple": 11, "deduction_head": 12,
+				"exemption_single": 13, "exemption_couple": 14, "exemption_dependent": 15},
+		},
+		{
+			name:    "state sheet without its group row",
+			header:  stateTestHeader,
+			columns: stateTestColumns,
+			want:    map[string]int{"state": 0},
+			missing: []string{"single_rate", "single_bracket", "married_rate", "married_bracket", "deduction_single",
+				"deduction_couple", "exemption_single", "exemption_couple", "exemption_dependent"},
+		},
+		{
+			name:     "first data row of the state sheet",
+			groupRow: stateTestHeader,
+			header:   []string{"Alabama", "0.02", ">", "0", "0.02", ">", "0", "2500", "7500", "1500", "3000", "1000"},
+			columns:  stateTestColumns,
+			want:     map[string]int{},
+			missing: []string{"state", "single_rate", "single_bracket", "married_rate", "married_bracket", "deduction_single",
+				"deduction_couple", "exemption_single", "exemption_couple", "exemption_dependent"},
+		},
+		{
+			name:     "federal bracket sheet",
+			groupRow: []string{"2022 Tax Brackets for Single Filers, Married Couples Filing Jointyl, and Heads of Households"},
+			header:   []string{"Rate", "For Unmarried Individuals", "For Married Individuals Filing Joint Returns", "For Heads of Households"},
+			columns:  federalBracketTestColumns,
+			want:     map[string]int{"rate": 0, "single": 1, "married": 2, "head": 3},
+		},
+		{
+			name: "federal capital gains sheet, with the rates under a blank header cell",
+			header: []string{" ", "For Unmarried Individuals, Taxable Income Over", "For Married Individuals Filing Joint Returns, Taxable Income Over",
+				"For Heads of Households, Taxable Income Over"},
+			columns: federalCapitalGainsTestColumns,
+			want:    map[string]int{"rate": 0, "single": 1, "married": 2, "head": 3},
+		},
+		{
+			name:    "federal earned income tax credit sheet",
+			header:  []string{"Filing Status", "", "No Children", "One Child", "Two Children", "Three or More Children"},
+			columns: federalEitcTestColumns,
+			want:    map[string]int{"status": 0, "parameter": 1, "children_0": 2, "children_1": 3, "children_2": 4, "children_3": 5},
+		},
+		{
+			name:    "federal earned income tax credit sheet missing the column of its relative column",
+			header:  []string{"No Children", "One Child", "Two Children", "Three or More Children"},
+			columns: federalEitcTestColumns,
+			want:    map[string]int{"children_0": 0, "children_1": 1, "children_2": 2, "children_3": 3},
+			missing: []string{"status", "parameter"},
+		},
+	}
+
+	for _, tt := range tests {
+		found, missing := findColumns(tt.header, tt.groupRow, tt.columns)
+		if !reflect.DeepEqual(found, tt.want) {
+			t.Errorf("%s: findColumns() = %v, want %v", tt.name, found, tt.want)
+		}
+		if !reflect.DeepEqual(missing, tt.missing) {
+			t.Errorf("%s: findColumns() missing = %v, want %v", tt.name, missing, tt.missing)
+		}
+	}
+}
+
+func TestOpenSourceTable(t *testing.T) {
+	tests := []struct {
+		filePath, sheet string
+		columns         []Column
+		// row of the first record and the cells of some of its columns
+		firstRow int
+		first    map[string]string
+		// part of the error expected, empty if none
+		err string
+	}{
+		{"../data/Local_Income_Tax_Rates_2019.xlsx", "Local Income Tax Rates", localTaxTestColumns,
+			1, map[string]string{"state": "Alabama", "jurisdiction": "Bessemer", "nonresident": "1.00%"}, ""},
+		{"../data/State-Individual-Income-Tax-Rates-and-Brackets-for-2022-v.xlsx", "2022", stateTestColumns,
+			2, map[string]string{"state": "Alabama", "single_bracket": "0", "married_rate": "0.02", "exemption_dependent": "1000", "head_rate": ""}, ""},
+		{"../data/2022-Federal-Income-Tax-Rates-and-Brackets-Tax-Foundation.xlsx", "Table 1", federalBracketTestColumns,
+			2, map[string]string{"rate": "10%", "head": "$0 to $14,650"}, ""},
+		// a sheet holding none of the columns names every missing column
+		{"../data/2022-Federal-Income-Tax-Rates-and-Brackets-Tax-Foundation.xlsx", "Table 2", localTaxTestColumns,
+			0, nil, "missing the required column(s) state, jurisdiction, resident, nonresident"},
+		// a sheet holding some of the columns names those missing from the row closest to a header
+		{"../data/Local_Income_Tax_Rates_2019.xlsx", "Local Income Tax Rates",
+			append([]Column{{Name: "county", Labels: []string{"County"}, Required: true}}, localTaxTestColumns...),
+			0, nil, "missing the required column(s) county"},
+	}
+
+	for _, tt := range tests {
+		records, err := OpenSourceTable(tt.filePath, tt.sheet, tt.columns)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("OpenSourceTable(%s, %s) returned error %v, want an error containing %q", tt.filePath, tt.sheet, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("OpenSourceTable(%s, %s) returned error %s", tt.filePath, tt.sheet, err)
+			continue
+		}
+		if len(records) == 0 || records[0].Row != tt.firstRow {
+			t.Errorf("OpenSourceTable(%s, %s) did not start its records at row %v", tt.filePath, tt.sheet, tt.firstRow)
+			continue
+		}
+		for name, want := range tt.first {
+			if got := records[0].Get(name); got != want {
+				t.Errorf("OpenSourceTable(%s, %s) first record %s = %q, want %q", tt.filePath, tt.sheet, name, got, want)
+			}
+		}
+	}
+}
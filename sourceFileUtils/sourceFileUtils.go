@@ -1,4 +1,4 @@
-/* Holds utility function for reading in data from excel files */
+/* Holds utility function for reading in data from source files */
 
 package sourcefileutils
 
@@ -6,21 +6,22 @@ import (
 	"fmt"
 
 	"github.com/Matthew-Curry/re-region-etl/logging"
-	"github.com/xuri/excelize/v2"
 )
 
 // logger for the package
 var logger, _ = logging.GetLogger("file.log")
 
-func OpenExcelSheet(filePath string, sheet string) ([][]string, error) {
-	f, err := excelize.OpenFile(filePath)
+// read in the rows of a sheet of a source file, using the reader of the file's format
+func OpenSourceSheet(filePath string, sheet string) ([][]string, error) {
+	r, err := NewSourceReader(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("There was an error reading in the excel file %s: %s", filePath, err)
+		return nil, err
 	}
+	defer r.Close()
 
-	rows, err := f.GetRows(sheet)
+	rows, err := r.Rows(sheet)
 	if err != nil {
-		return nil, fmt.Errorf("There was an error reading in the rows of the excel file %s: %s", filePath, err)
+		return nil, fmt.Errorf("There was an error reading in the rows of the source file %s: %s", filePath, err)
 	}
 
 	logger.Info("Loaded the file %s successfully", filePath)
@@ -0,0 +1,254 @@
+/* Holds the readers of the source file formats, all reading a file as sheets of rows of cells */
+
+package sourcefileutils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// a reader of the sheets of a source file. Cells are read as the text shown in the file, and trailing empty cells
+// of a row are dropped.
+type SourceReader interface {
+	// names of the sheets of the file, in order
+	Sheets() []string
+	// rows of the named sheet
+	Rows(sheet string) ([][]string, error)
+	Close() error
+}
+
+// constructors of the source readers by file extension
+var sourceReaders = map[string]func(filePath string) (SourceReader, error){
+	".xlsx": newXlsxReader,
+	".csv":  newCsvReader,
+	".json": newJsonReader,
+	".ods":  newOdsReader,
+}
+
+// return whether the file is in a format a source reader exists for
+func IsSourceFile(filePath string) bool {
+	_, ok := sourceReaders[strings.ToLower(filepath.Ext(filePath))]
+	return ok
+}
+
+// open a source file with the reader of its extension
+func NewSourceReader(filePath string) (SourceReader, error) {
+	newReader, ok := sourceReaders[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("There is no reader for the format of the source file %s", filePath)
+	}
+
+	r, err := newReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("There was an error reading in the source file %s: %s", filePath, err)
+	}
+
+	return r, nil
+}
+
+// reader of excel workbooks
+type xlsxReader struct {
+	f *excelize.File
+}
+
+func newXlsxReader(filePath string) (SourceReader, error) {
+	f, err := excelize.OpenFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &xlsxReader{f: f}, nil
+}
+
+func (r *xlsxReader) Sheets() []string {
+	return r.f.GetSheetList()
+}
+
+func (r *xlsxReader) Rows(sheet string) ([][]string, error) {
+	return r.f.GetRows(sheet)
+}
+
+func (r *xlsxReader) Close() error {
+	return r.f.Close()
+}
+
+// reader of files holding their sheets in memory, used by the formats without random access
+type memoryReader struct {
+	sheets []string
+	rows   map[string][][]string
+}
+
+func (r *memoryReader) Sheets() []string {
+	return r.sheets
+}
+
+func (r *memoryReader) Rows(sheet string) ([][]string, error) {
+	rows, ok := r.rows[sheet]
+	if !ok {
+		return nil, fmt.Errorf("sheet %s does not exist", sheet)
+	}
+
+	return rows, nil
+}
+
+func (r *memoryReader) Close() error {
+	return nil
+}
+
+// helper method returning the name of the single sheet of a file without sheets, its file name without extension
+func fileSheetName(filePath string) string {
+	base := filepath.Base(filePath)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
+
+// reader of comma delimited files. The file is a single sheet named after the file. Blank lines are not read as rows.
+func newCsvReader(filePath string) (SourceReader, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	cr := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))))
+	// rows may have any number of cells, as in a sheet
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, row := range rows {
+		rows[i] = trimRow(row)
+	}
+
+	name := fileSheetName(filePath)
+	return &memoryReader{sheets: []string{name}, rows: map[string][][]string{name: rows}}, nil
+}
+
+// reader of json files. The file is either a single sheet named after the file, or an object of sheets keyed by
+// name. A sheet is an array of rows, each an array of cells, an object of cells keyed by column label or null if
+// empty. Rows of objects are read below a header row holding the labels of all of the rows in sorted order.
+func newJsonReader(filePath string) (SourceReader, error) {
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	// keep numbers as written
+	d.UseNumber()
+	err = d.Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &memoryReader{rows: make(map[string][][]string)}
+	switch v := doc.(type) {
+	case []interface{}:
+		name := fileSheetName(filePath)
+		rows, err := jsonSheetRows(v)
+		if err != nil {
+			return nil, err
+		}
+		r.sheets = []string{name}
+		r.rows[name] = rows
+	case map[string]interface{}:
+		// an object does not keep the order of its keys, so sheets are in order of name
+		for name := range v {
+			r.sheets = append(r.sheets, name)
+		}
+		sort.Strings(r.sheets)
+		for _, name := range r.sheets {
+			sheet, ok := v[name].([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("sheet %s is not an array of rows", name)
+			}
+			rows, err := jsonSheetRows(sheet)
+			if err != nil {
+				return nil, fmt.Errorf("sheet %s: %s", name, err)
+			}
+			r.rows[name] = rows
+		}
+	default:
+		return nil, fmt.Errorf("expected an array of rows or an object of sheets")
+	}
+
+	return r, nil
+}
+
+// helper method to convert the rows of a json sheet to rows of cells
+func jsonSheetRows(sheet []interface{}) ([][]string, error) {
+	// labels of the rows of objects, in sorted order
+	labelSet := make(map[string]bool)
+	for _, row := range sheet {
+		if obj, ok := row.(map[string]interface{}); ok {
+			for label := range obj {
+				labelSet[label] = true
+			}
+		}
+	}
+	var labels []string
+	for label := range labelSet {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	var rows [][]string
+	if len(labels) > 0 {
+		rows = append(rows, labels)
+	}
+
+	for i, row := range sheet {
+		var cells []string
+		switch v := row.(type) {
+		case nil:
+			// an empty row
+		case []interface{}:
+			for _, cell := range v {
+				cells = append(cells, jsonCellText(cell))
+			}
+		case map[string]interface{}:
+			for _, label := range labels {
+				cells = append(cells, jsonCellText(v[label]))
+			}
+		default:
+			return nil, fmt.Errorf("row %v is not an array or object of cells", i)
+		}
+		rows = append(rows, trimRow(cells))
+	}
+
+	return rows, nil
+}
+
+// helper method returning the text of a json cell. Null and missing cells are empty.
+func jsonCellText(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// helper method to drop the trailing empty cells of a row. An empty row is nil, as the excel reader reads it.
+func trimRow(row []string) []string {
+	end := len(row)
+	for end > 0 && row[end-1] == "" {
+		end--
+	}
+	if end == 0 {
+		return nil
+	}
+
+	return row[:end]
+}
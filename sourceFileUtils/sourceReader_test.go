@@ -0,0 +1,72 @@
+package sourcefileutils
+
+import (
+	"reflect"
+	"testing"
+)
+
+// rows of the local_2019 sheet of the fixtures under testdata, the same in every format. The fixtures hold an empty
+// cell within a row, empty rows, a cell of several lines and a run of spaces.
+var testLocalRows = [][]string{
+	{"State", "Taxing Jurisdiction", "Resident", "Nonresident"},
+	{"Alabama", "Bessemer", "1.00%", "1.00%"},
+	{"", "Birmingham", "1.00%", "1.00%"},
+	{"", "Gadsden  city", "2.00%", "2.00%"},
+	{"", "Gadsden  city", "2.00%", "2.00%"},
+	nil,
+	nil,
+	{"Ohio", "", "", "1.00%"},
+	{"(a) Local income taxes\nare excluded."},
+}
+
+// helper method reading the rows of a sheet of a fixture with the reader of its format
+func readTestSheet(t *testing.T, filePath, sheet string) ([]string, [][]string) {
+	r, err := NewSourceReader(filePath)
+	if err != nil {
+		t.Fatalf("NewSourceReader(%s) returned error %s", filePath, err)
+	}
+	defer r.Close()
+
+	rows, err := r.Rows(sheet)
+	if err != nil {
+		t.Fatalf("Rows(%s) of %s returned error %s", sheet, filePath, err)
+	}
+
+	return r.Sheets(), rows
+}
+
+func TestSourceReadersMatchXlsx(t *testing.T) {
+	_, xlsxRows := readTestSheet(t, "testdata/local_2019.xlsx", "local_2019")
+	if !reflect.DeepEqual(xlsxRows, testLocalRows) {
+		t.Fatalf("rows of local_2019.xlsx = %q, want %q", xlsxRows, testLocalRows)
+	}
+
+	tests := []struct {
+		filePath string
+		sheets   []string
+	}{
+		{"testdata/local_2019.csv", []string{"local_2019"}},
+		{"testdata/local_2019.json", []string{"local_2019"}},
+		{"testdata/local_2019_sheets.json", []string{"local_2019", "notes"}},
+		{"testdata/local_2019.ods", []string{"local_2019"}},
+	}
+
+	for _, tt := range tests {
+		sheets, rows := readTestSheet(t, tt.filePath, "local_2019")
+		if !reflect.DeepEqual(sheets, tt.sheets) {
+			t.Errorf("sheets of %s = %q, want %q", tt.filePath, sheets, tt.sheets)
+		}
+		if !reflect.DeepEqual(rows, xlsxRows) {
+			t.Errorf("rows of %s = %q, want the rows of the workbook %q", tt.filePath, rows, xlsxRows)
+		}
+	}
+}
+
+func TestJsonObjectRows(t *testing.T) {
+	_, rows := readTestSheet(t, "testdata/local_2019_sheets.json", "notes")
+	// rows of objects are read below a header of their labels in sorted order
+	want := [][]string{{"Note", "Text"}, {"(a)", "Local income taxes are excluded."}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows of the notes sheet = %q, want %q", rows, want)
+	}
+}